@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/html"
+
+	"github.com/spf13/cobra"
+)
+
+const configHelp = `Provide a configuration file using one of the following methods:
+1. Use the --config <path> or -c <path> flag.
+2. Place a config.toml file in the default user configuration directory (e.g., ~/.config/tcb-bot/).
+3. Place a config.toml file a folder inside your home directory (e.g., ~/.tcb-bot/).
+4. Place a config.toml file in the directory of the binary.`
+
+// newRootCommand builds the tcb-bot command with all of its sub-commands, every sub-command has its own flags
+// besides --config and --config-check.
+func newRootCommand() *cobra.Command {
+	var configPath string
+	var configCheck bool
+
+	root := &cobra.Command{
+		Use:   "tcb-bot",
+		Short: "A Discord bot to notify you about the latest manga chapters released by TCB.",
+		Long:  "A Discord bot to notify you about the latest manga chapters released by TCB.\n\n" + configHelp,
+		// validate the config without opening Discord or the database, e.g. before deploying a changed config
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if !configCheck {
+				return
+			}
+
+			if err := config.Check(configPath); err != nil {
+				fmt.Printf("Config is invalid:\n%s\n", config.FormatCheckError(err))
+				os.Exit(1)
+			}
+			fmt.Println("Config is valid")
+			os.Exit(0)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+	root.PersistentFlags().StringVarP(&configPath, "config", "c", "",
+		"Path to the directory of config.toml (default is in the default user config directory)")
+	root.PersistentFlags().BoolVar(&configCheck, "config-check", false,
+		"Validate the configuration file and exit without starting tcb-bot")
+	root.RegisterFlagCompletionFunc("config", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+
+	root.AddCommand(
+		newStartCommand(&configPath),
+		newVersionCommand(),
+		newBackupCommand(&configPath),
+		newRestoreCommand(&configPath),
+		newDBCommand(&configPath),
+		newMigrateConfigCommand(),
+		newPreviewCommand(&configPath),
+		newListMangasCommand(&configPath),
+	)
+
+	return root
+}
+
+func newStartCommand(configPath *string) *cobra.Command {
+	var opts startOptions
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start tcb-bot",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.logFormat != "" && opts.logFormat != "json" && opts.logFormat != domain.LogFormatConsole {
+				return fmt.Errorf("--log-format must be json or console, got %q", opts.logFormat)
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			start(*configPath, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Check for new chapters without saving or announcing them")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false,
+		"Log every scrape request, response and chapter card, implies DEBUG logging")
+	cmd.Flags().StringVar(&opts.logFormat, "log-format", "",
+		"Write json or console logs to stderr, overrides logFormat")
+	cmd.Flags().BoolVar(&opts.once, "once", false, "Check for new chapters once and exit")
+	cmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions([]string{"json", domain.LogFormatConsole},
+		cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func newVersionCommand() *cobra.Command {
+	var checkVersion bool
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version info",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			printVersion(checkVersion)
+		},
+	}
+	cmd.Flags().BoolVar(&checkVersion, "check", false, "Exit with code 1 if a newer release is available")
+
+	return cmd
+}
+
+func newBackupCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:               "backup <path>",
+		Short:             "Back up the collected chapters database to path",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstFile,
+		Run: func(cmd *cobra.Command, args []string) {
+			backupDB(*configPath, args[0])
+		},
+	}
+}
+
+func newRestoreCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:               "restore <path>",
+		Short:             "Restore the collected chapters database from path, stop tcb-bot first",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstFile,
+		Run: func(cmd *cobra.Command, args []string) {
+			restoreDB(*configPath, args[0])
+		},
+	}
+}
+
+func newDBCommand(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect and maintain the collected chapters database",
+	}
+
+	var olderThan string
+	var yes bool
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete chapters released before --older-than from the database, stop tcb-bot first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			pruneDB(*configPath, olderThan, yes)
+		},
+	}
+	prune.Flags().StringVar(&olderThan, "older-than", "",
+		"Delete chapters released before this duration, e.g. 365d, supports the units w, d, h, m and s")
+	prune.Flags().BoolVar(&yes, "yes", false, "Don't ask for confirmation")
+	prune.RegisterFlagCompletionFunc("older-than", cobra.NoFileCompletions)
+
+	var manga string
+	var confirmed bool
+	reset := &cobra.Command{
+		Use:   "reset",
+		Short: "Delete all chapters or those of --manga, so they're announced again, stop tcb-bot first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			resetDB(*configPath, manga, confirmed)
+		},
+	}
+	reset.Flags().StringVar(&manga, "manga", "", "Only reset the chapters of this manga")
+	reset.Flags().BoolVar(&confirmed, "confirm", false, "Required to reset the chapters of all mangas")
+	reset.RegisterFlagCompletionFunc("manga", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return watchedMangas(*configPath, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "Print statistics and the integrity of the database, exits with code 1 if it's corrupt",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			printDBStats(*configPath)
+		},
+	}
+
+	cmd.AddCommand(prune, reset, stats)
+
+	return cmd
+}
+
+func newMigrateConfigCommand() *cobra.Command {
+	var from string
+	var to string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate-config",
+		Short: "Convert the legacy YAML config at --from into a TOML config at --to",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			migrateConfig(from, to, yes)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Path of the legacy config.yaml")
+	cmd.Flags().StringVar(&to, "to", "", "Path of the config.toml to write")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Don't ask for confirmation")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagFilename("from", "yaml", "yml")
+	cmd.MarkFlagFilename("to", "toml")
+
+	return cmd
+}
+
+func newPreviewCommand(configPath *string) *cobra.Command {
+	var selector string
+
+	cmd := &cobra.Command{
+		Use:   "preview <url>",
+		Short: "Print the chapter cards parsed from url as JSON, without saving or announcing them",
+		Long: "Print the chapter cards parsed from url as JSON, without saving or announcing them.\n\n" +
+			"Use --selector to test another chapter card selector after the structure of the website changed.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(cmd *cobra.Command, args []string) {
+			preview(*configPath, args[0], selector)
+		},
+	}
+	cmd.Flags().StringVar(&selector, "selector", html.ChapterCardSelector, "CSS selector of the chapter cards")
+	cmd.RegisterFlagCompletionFunc("selector", cobra.NoFileCompletions)
+
+	return cmd
+}
+
+func newListMangasCommand(configPath *string) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list-mangas",
+		Short: "Print the titles of all mangas with a chapter on the website, to find the titles to watch",
+		Long: "Print the titles of all mangas with a chapter on the website, to find the titles to watch.\n\n" +
+			"Only the scrape options of the config are used, Discord doesn't need to be configured.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listMangas(*configPath, asJSON)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the titles as a JSON array")
+
+	return cmd
+}
+
+// completeFirstFile completes the path argument of a command using files.
+func completeFirstFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// watchedMangas returns the watched mangas starting with prefix. Completing must never create a config, so nothing
+// is completed if the config doesn't exist yet.
+func watchedMangas(configPath string, prefix string) []string {
+	if configPath != "" {
+		if _, err := os.Stat(filepath.Join(configPath, "config.toml")); err != nil {
+			return nil
+		}
+	}
+
+	cfg := config.New(configPath, version)
+
+	var mangas []string
+	for _, manga := range cfg.Config.AllWatchedMangas() {
+		if manga != domain.WatchAllMangas && strings.HasPrefix(strings.ToLower(manga), strings.ToLower(prefix)) {
+			mangas = append(mangas, manga)
+		}
+	}
+
+	return mangas
+}
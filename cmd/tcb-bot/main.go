@@ -1,23 +1,40 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"tcb-bot/internal/api"
 	"tcb-bot/internal/config"
 	"tcb-bot/internal/database"
 	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/hiatus"
 	"tcb-bot/internal/html"
 	"tcb-bot/internal/logger"
+	"tcb-bot/internal/metrics"
+	"tcb-bot/internal/otel"
+	"tcb-bot/internal/server"
+	"tcb-bot/internal/smtp"
+	"tcb-bot/internal/telegram"
+	"tcb-bot/internal/utils"
 
+	"github.com/getsentry/sentry-go"
 	"github.com/go-co-op/gocron/v2"
-	"github.com/spf13/pflag"
+	"golang.org/x/mod/semver"
 )
 
 var (
@@ -26,181 +43,997 @@ var (
 	date    = ""
 )
 
-const usage = `A Discord bot to notify you about the latest manga chapters released by TCB.
+const checkJobTag = "check"
 
-Usage:
-  tcb-bot [command] [flags]
+// sentryFlushTimeout is how long pending Sentry events may take to be sent on shutdown
+const sentryFlushTimeout = 2 * time.Second
 
-Commands:
-  start          Start tcb-bot
-  version        Print version info
-  help           Show this help message
+// traceFlushTimeout is how long pending spans may take to be exported on shutdown
+const traceFlushTimeout = 5 * time.Second
 
-Flags:
-  -c, --config <path>  Path to configuration file (default is in the default user config directory)
-
-Provide a configuration file using one of the following methods:
-1. Use the --config <path> or -c <path> flag.
-2. Place a config.toml file in the default user configuration directory (e.g., ~/.config/tcb-bot/).
-3. Place a config.toml file a folder inside your home directory (e.g., ~/.tcb-bot/).
-4. Place a config.toml file in the directory of the binary.
-` + "\n"
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
 
-func init() {
-	pflag.Usage = func() {
-		fmt.Print(usage)
+// newLogger creates the logger of a command, it exits if the directory of the log file can't be created.
+func newLogger(cfg *config.AppConfig) logger.Logger {
+	log, err := logger.New(cfg.Config)
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
 	}
+
+	return log
 }
 
-func main() {
-	var configPath string
-	var lastError string
+// printVersion prints the version and the latest release. If checkVersion is set, it exits with code 1 if a newer
+// release is available.
+func printVersion(checkVersion bool) {
+	fmt.Printf("Version: %v\nCommit: %v\n", version, commit)
 
-	pflag.StringVarP(&configPath, "config", "c", "", "Specifies the path for the config file.")
-	pflag.Parse()
+	// development builds are never outdated
+	if checkVersion && version == "dev" {
+		fmt.Println("Development build, skipping update check")
+		os.Exit(0)
+	}
 
-	switch cmd := pflag.Arg(0); cmd {
-	case "version":
-		fmt.Printf("Version: %v\nCommit: %v\n", version, commit)
+	// get the latest release tag from api, the config isn't loaded here so the proxy is read from the environment
+	client := utils.NewHTTPClient(os.Getenv("TCB_BOT__SCRAPE_PROXY_URL"), 10*time.Second)
 
-		// get the latest release tag from api
-		client := http.Client{
-			Timeout: 10 * time.Second,
+	resp, err := client.Get("https://api.github.com/repos/nuxencs/tcb-bot/releases/latest")
+	if err != nil {
+		if errors.Is(err, http.ErrHandlerTimeout) {
+			fmt.Println("Server timed out while fetching latest release from api")
+		} else {
+			fmt.Printf("Failed to fetch latest release from api: %v\n", err)
 		}
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
 
-		resp, err := client.Get("https://api.github.com/repos/nuxencs/tcb-bot/releases/latest")
-		if err != nil {
-			if errors.Is(err, http.ErrHandlerTimeout) {
-				fmt.Println("Server timed out while fetching latest release from api")
-			} else {
-				fmt.Printf("Failed to fetch latest release from api: %v\n", err)
-			}
-			os.Exit(1)
-		}
-		defer resp.Body.Close()
+	// api returns 500 instead of 404 here
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusInternalServerError {
+		fmt.Print("No release found")
+		os.Exit(1)
+	}
+
+	var rel struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		fmt.Printf("Failed to decode response from api: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Latest release: %v\n", rel.TagName)
 
-		// api returns 500 instead of 404 here
-		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusInternalServerError {
-			fmt.Print("No release found")
+	if checkVersion {
+		current, latest := semverTag(version), semverTag(rel.TagName)
+		if !semver.IsValid(current) || !semver.IsValid(latest) {
+			fmt.Printf("Failed to compare versions %q and %q\n", version, rel.TagName)
 			os.Exit(1)
 		}
 
-		var rel struct {
-			TagName string `json:"tag_name"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
-			fmt.Printf("Failed to decode response from api: %v\n", err)
+		if semver.Compare(current, latest) < 0 {
+			fmt.Printf("tcb-bot is outdated, upgrade to %v with `docker pull ghcr.io/nuxencs/tcb-bot:latest` or "+
+				"download it from https://github.com/nuxencs/tcb-bot/releases/latest\n", rel.TagName)
 			os.Exit(1)
 		}
-		fmt.Printf("Latest release: %v\n", rel.TagName)
+		fmt.Println("tcb-bot is up to date")
+	}
+}
+
+// backupDB backs up the collected chapters database to destination.
+func backupDB(configPath string, destination string) {
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	db := database.NewDB(log, cfg, domain.NewSyncMapStore())
+	if err := db.Open(); err != nil {
+		fmt.Printf("Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	chapters, err := db.Backup(context.Background(), destination)
+	if err != nil {
+		fmt.Printf("Failed to back up database: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Backed up %d chapters to %s\n", chapters, destination)
+}
+
+// preview prints the chapter cards matching selector on websiteURL as JSON.
+func preview(configPath string, websiteURL string, selector string) {
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	chapters, cardErrs, err := html.Preview(log, cfg, websiteURL, selector)
+	if errors.Is(err, html.ErrNoChapterCards) {
+		fmt.Printf("Selector %q matched no elements on %s, the structure of the website may have changed. "+
+			"Try another selector using --selector.\n", selector, websiteURL)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Printf("Failed to fetch %s: %v\n", websiteURL, err)
+		os.Exit(1)
+	}
+
+	// stdout only contains the JSON, so it can be piped
+	for _, err := range cardErrs {
+		fmt.Fprintf(os.Stderr, "Failed to parse chapter card: %v\n", err)
+	}
+
+	if chapters == nil {
+		chapters = []domain.ChapterInfo{}
+	}
+	out, err := json.MarshalIndent(chapters, "", "  ")
+	if err != nil {
+		fmt.Printf("Failed to encode chapters: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
 
-	case "start":
-		// read config
-		cfg := config.New(configPath, version)
+	if len(chapters) == 0 {
+		os.Exit(1)
+	}
+}
 
-		// init new logger
-		log := logger.New(cfg.Config)
+// listMangas prints the titles of all mangas on the website, one per line or as a JSON array.
+func listMangas(configPath string, asJSON bool) {
+	cfg := config.NewScrapeConfig(configPath, version)
+	log := newLogger(cfg)
 
-		if err := cfg.UpdateConfig(); err != nil {
-			log.Error().Err(err).Msgf("error updating config")
+	mangas, err := html.ListMangas(log, cfg)
+	if errors.Is(err, html.ErrNoChapterCards) {
+		fmt.Println("Found no chapter cards, the structure of the website may have changed.")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Printf("Failed to list mangas: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !asJSON {
+		for _, manga := range mangas {
+			fmt.Println(manga)
 		}
+		return
+	}
+
+	out, err := json.MarshalIndent(mangas, "", "  ")
+	if err != nil {
+		fmt.Printf("Failed to encode mangas: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+// restoreDB restores the collected chapters database from source.
+func restoreDB(configPath string, source string) {
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	db := database.NewDB(log, cfg, domain.NewSyncMapStore())
+	chapters, err := db.Restore(context.Background(), source)
+	if err != nil {
+		fmt.Printf("Failed to restore database: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored %d chapters from %s\n", chapters, source)
+}
+
+// pruneDB deletes the chapters released before olderThan, after asking for confirmation unless yes is set.
+func pruneDB(configPath string, olderThan string, yes bool) {
+	age, err := utils.ParseDuration(olderThan)
+	if err != nil || age <= 0 {
+		fmt.Println("Please provide a positive duration using --older-than, e.g. --older-than 365d")
+		os.Exit(1)
+	}
+
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	db := database.NewDB(log, cfg, domain.NewSyncMapStore())
+	if err := db.Open(); err != nil {
+		fmt.Printf("Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
 
-		// init dynamic config
-		cfg.DynamicReload(log)
+	ctx := context.Background()
+	cutoff := time.Now().Add(-age)
 
-		// init new db
-		db := database.NewDB(log, cfg)
-		if err := db.Open(); err != nil {
-			log.Fatal().Err(err).Msg("error opening db connection")
+	releaseTitles, err := db.ChaptersReleasedBefore(ctx, cutoff)
+	if err != nil {
+		fmt.Printf("Failed to find chapters to prune: %v\n", err)
+		os.Exit(1)
+	}
+	if len(releaseTitles) == 0 {
+		fmt.Printf("No chapters released before %s\n", cutoff.Format(time.RFC1123))
+		return
+	}
+
+	fmt.Printf("%d chapters were released before %s\n", len(releaseTitles), cutoff.Format(time.RFC1123))
+	if !yes && !confirm("Delete them?") {
+		fmt.Println("Aborted")
+		return
+	}
+
+	deleted, err := db.PruneChapters(ctx, releaseTitles)
+	if err != nil {
+		fmt.Printf("Failed to prune chapters: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Deleted %d chapters\n", deleted)
+}
+
+// migrateConfig converts the legacy YAML config at from into a TOML config at to.
+func migrateConfig(from string, to string, yes bool) {
+	// the config of a new installation only contains the template, but it could already be customized
+	if _, err := os.Stat(to); err == nil && !yes && !confirm(fmt.Sprintf("Overwrite %s?", to)) {
+		fmt.Println("Aborted")
+		os.Exit(1)
+	}
+
+	skipped, err := config.MigrateYAML(from, to)
+	if err != nil {
+		fmt.Printf("Failed to migrate config: %v\n", err)
+		os.Exit(1)
+	}
+	for _, key := range skipped {
+		fmt.Printf("Skipped unknown setting: %s\n", key)
+	}
+	fmt.Printf("Migrated %s to %s, check the new config using --config-check\n", from, to)
+}
+
+// startOptions are set using the flags of the start command.
+type startOptions struct {
+	dryRun    bool
+	verbose   bool
+	logFormat string
+	once      bool
+}
+
+// start runs tcb-bot until it receives a shutdown signal, or checks once if opts.once is set.
+func start(configPath string, opts startOptions) {
+	// read config
+	cfg := config.New(configPath, version)
+	cfg.Config.DryRun = opts.dryRun
+	// verbose mode may log sensitive data, so it can't be enabled in the config file
+	cfg.Config.Verbose = opts.verbose
+	if opts.logFormat != "" {
+		cfg.Config.LogFormat = opts.logFormat
+	}
+
+	// init new logger
+	log := newLogger(cfg)
+
+	// report errors to sentry, the logger sends every error to it from now on
+	if cfg.Config.SentryDSN != "" {
+		if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.Config.SentryDSN, Release: version}); err != nil {
+			log.Error().Err(err).Msg("error initializing sentry")
 		}
+	}
 
-		log.Info().Msgf("Starting tcb-bot")
-		log.Info().Msgf("Version: %s", version)
-		log.Info().Msgf("Commit: %s", commit)
-		log.Info().Msgf("Build date: %s", date)
-		log.Info().Msgf("Log-level: %s", cfg.Config.LogLevel)
+	// trace checks and notifications, the tracer is a no-op without otelEndpoint
+	shutdownTracing, err := otel.Init(context.Background(), log, cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("error initializing OpenTelemetry")
+		shutdownTracing = func(context.Context) error { return nil }
+	}
 
-		// init new discord bot
-		bot := discord.NewBot(log, cfg)
+	if err := cfg.UpdateConfig(); err != nil {
+		log.Error().Err(err).Msgf("error updating config")
+	}
+
+	// init dynamic config
+	cfg.DynamicReload(log)
+
+	// banners are only checked for warnings, so the bot doesn't wait for them
+	go discord.CheckBanners(log, cfg)
+	cfg.OnReload(func() { go discord.CheckBanners(log, cfg) })
+
+	// init new db
+	chapters := domain.NewSyncMapStore()
+	db := database.NewDB(log, cfg, chapters)
+	if err := db.Open(); err != nil {
+		log.Fatal().Err(err).Msg("error opening db connection")
+	}
+
+	logStartup(log, cfg)
+	if cfg.Config.Verbose {
+		log.Warn().Msg("Verbose mode: every scrape request and response is logged, the logs may contain sensitive data")
+	}
+	if cfg.Config.DryRun {
+		log.Info().Msg("Dry run: new chapters are neither saved nor announced")
+	}
+	if opts.once {
+		log.Info().Msg("Running in one-shot mode.")
+	}
+	if cfg.Config.WatchesAll() {
+		log.Warn().Msgf("Watching all mangas, every chapter released on the site is announced. Checking every %d minutes",
+			cfg.Config.SleepTimer)
+	}
+
+	// init new notifier, only use the discord webhook if there is no bot token
+	var notifier discord.Notifier
+	var bot *discord.Bot
+	if cfg.Config.Notifier == "telegram" {
+		notifier = telegram.NewTelegramNotifier(log, cfg)
+	} else if cfg.Config.Notifier == "smtp" {
+		notifier = smtp.NewSMTPNotifier(log, cfg)
+	} else if cfg.Config.DiscordWebhookURL != "" && cfg.Config.DiscordToken == "" {
+		webhook, err := discord.NewWebhookNotifier(log, cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("error creating discord webhook notifier")
+		}
+		notifier = webhook
+	} else {
+		bot = discord.NewBot(log, cfg, chapters)
 		if err := bot.Open(); err != nil {
 			log.Fatal().Err(err).Msg("error opening discord session")
 		}
+		notifier = bot
+	}
 
-		// load collected chapters
-		db.LoadCollectedChapters()
+	if cfg.Config.Notifier == "both" {
+		notifier = discord.MultiNotifier{
+			{Name: "discord", Notifier: notifier},
+			{Name: "telegram", Notifier: telegram.NewTelegramNotifier(log, cfg)},
+		}
+	}
+
+	// hold back chapter notifications during quiet hours, only log them during a dry run. A single check
+	// exits right away, so there is nothing to hold them back for.
+	if cfg.Config.DryRun {
+		notifier = discord.NewDryRunNotifier(log)
+	} else if !opts.once {
+		notifier = discord.NewNotificationQueue(log, cfg, notifier)
+	}
 
-		// init new collector
-		c := html.NewCollector(log, cfg, bot, db)
+	// ctx is cancelled on shutdown, so in-flight checks stop early
+	ctx, cancel := context.WithCancel(context.Background())
 
-		// init new scheduler
-		s, err := gocron.NewScheduler()
+	// load collected chapters
+	db.LoadCollectedChapters(ctx)
+
+	// init new hiatus checker
+	h := hiatus.NewChecker(log, cfg, chapters, notifier, db)
+
+	// the last error and the circuit state are restored from the previous run
+	checkErrs := newCheckErrors(ctx, log, cfg, db, notifier)
+
+	// init new collector
+	collector := html.NewCollector(log, cfg, chapters, notifier, db, h).
+		WithCircuitStateHook(checkErrs.SetCircuitState).
+		WithChapterFoundHook(metrics.NotificationSent).
+		WithScrapeDoneHook(func(duration time.Duration, err error) {
+			metrics.ObserveScrape(duration, err)
+			metrics.SetChaptersCollected(domain.CountChapters(chapters))
+		})
+	collector.RestoreCircuitState(checkErrs.CircuitState())
+	if bot != nil {
+		bot.SetCheckFunc(collector.Check)
+		bot.SetHistoryFunc(db.ListChaptersByManga)
+		bot.SetStatsFunc(db.MangaStats)
+		bot.SetMarkReadFunc(db.MarkChapterRead)
+		bot.SetSearchFunc(db.SearchChapters)
+	}
+
+	// check once without the scheduler and http server, e.g. when started by a systemd timer
+	if opts.once {
+		// notifications that failed in earlier runs are only retried by the retry job, which doesn't run here
+		if !cfg.Config.DryRun {
+			if err := collector.RetryNotifications(ctx); err != nil {
+				log.Error().Err(err).Msg("error retrying notifications")
+			}
+		}
+
+		err := collector.Run(ctx)
 		if err != nil {
-			log.Error().Err(err).Msg("error creating scheduler")
+			log.Error().Err(err).Msg("error checking for new chapters")
+		}
+		cancel()
+
+		var steps []shutdownStep
+		if !cfg.Config.DryRun {
+			steps = append(steps, shutdownStep{"saving collected chapters", db.SaveCollectedChapters})
+			steps = append(steps, shutdownStep{"saving bot state", checkErrs.Save})
+		}
+		if bot != nil {
+			steps = append(steps, shutdownStep{"closing discord session", bot.Close})
+		}
+		steps = append(steps, shutdownStep{"closing db connection", func(ctx context.Context) error {
+			return withContext(ctx, db.Close)
+		}})
+
+		shutdownOK := shutdown(log, cfg, steps)
+		sentry.Flush(sentryFlushTimeout)
+		flushTraces(log, shutdownTracing)
+
+		if err != nil || !shutdownOK {
 			os.Exit(1)
 		}
+		os.Exit(0)
+	}
+
+	// init http server for health checks, feed and api
+	srv := server.NewServer(log, cfg, chapters, bot, db)
+	api.NewHandler(log, cfg, chapters, db, collector.Check).RegisterRoutes(srv.Mux())
+	if cfg.Config.HealthCheckPort != 0 {
+		if err := srv.Open(); err != nil {
+			log.Fatal().Err(err).Msg("error starting http server")
+		}
+	}
+
+	// init new scheduler
+	s, err := gocron.NewScheduler(gocron.WithStopTimeout(
+		time.Duration(cfg.Config.ShutdownTimeoutSeconds) * time.Second))
+	if err != nil {
+		log.Error().Err(err).Msg("error creating scheduler")
+		os.Exit(1)
+	}
 
-		// init new job
+	// init check jobs, one per watched manga, they are recreated whenever the config is reloaded
+	if err := scheduleChecks(ctx, s, log, cfg, collector, checkErrs); err != nil {
+		log.Error().Err(err).Msg("error creating task")
+		os.Exit(1)
+	}
+	cfg.OnReload(func() {
+		if err := scheduleChecks(ctx, s, log, cfg, collector, checkErrs); err != nil {
+			log.Error().Err(err).Msg("error recreating check tasks")
+		}
+	})
+
+	// init new hiatus job
+	_, err = s.NewJob(
+		gocron.DurationJob(time.Hour),
+		gocron.NewTask(
+			recovered(log, notifier, func() {
+				if err := h.Run(ctx); err != nil {
+					log.Error().Err(err).Msg("error checking for hiatus")
+				}
+			}),
+		),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("error creating hiatus task")
+		os.Exit(1)
+	}
+
+	// init notification retry job, failed chapter notifications are queued instead of being lost
+	if !cfg.Config.DryRun {
 		_, err = s.NewJob(
-			gocron.CronJob(
-				fmt.Sprintf("*/%d * * * *", cfg.Config.SleepTimer),
-				false,
-			),
+			gocron.DurationJob(time.Minute),
 			gocron.NewTask(
-				func() {
-					err := c.Run()
-					if err != nil {
-						log.Error().Err(err).Msg("error collecting chapters")
-						currentError := fmt.Sprintf("Unexpected error occurred: %v", err)
-						if currentError != lastError {
-							bot.SendDiscordNotification("Error collecting chapters", currentError,
-								"", "", 10038562)
-							lastError = currentError
-						}
-					} else if lastError != "" {
-						log.Info().Msg("error has been resolved")
-						bot.SendDiscordNotification("Error resolved", "The previous error has been resolved",
-							"", "", 15105570)
-						lastError = ""
+				recovered(log, notifier, func() {
+					if err := collector.RetryNotifications(ctx); err != nil && !errors.Is(err, context.Canceled) {
+						log.Error().Err(err).Msg("error retrying notifications")
 					}
-				},
+				}),
 			),
 		)
 		if err != nil {
-			log.Error().Err(err).Msg("error creating task")
+			log.Error().Err(err).Msg("error creating notification retry task")
 			os.Exit(1)
 		}
+	}
+
+	// check right away, chapters released while the bot was offline shouldn't wait for the first sleep timer
+	if cfg.Config.CheckOnStartup {
+		log.Info().Msg("Checking for new chapters on startup")
+		err := collector.Run(ctx)
+		if err != nil && !errors.Is(err, html.ErrCircuitOpen) && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("error checking for new chapters on startup")
+		}
+	}
 
-		s.Start()
+	s.Start()
 
-		// Set up a channel to catch signals for graceful shutdown
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	// Set up a channel to catch signals for config reloads and graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 
+	// the bot gives up after failing to reconnect to Discord, tcb-bot shuts down gracefully then
+	var botDone <-chan struct{}
+	if bot != nil {
+		botDone = bot.Done()
+	}
+
+loop:
+	for {
+		var sig os.Signal
 		select {
-		case sig := <-sigCh:
+		case <-botDone:
+			log.Info().Msg("lost connection to Discord, shutting down bot.")
+			break loop
+		case sig = <-sigCh:
+		}
+
+		if sig != syscall.SIGHUP {
 			log.Info().Msgf("received signal: %q, shutting down bot.", sig.String())
+			break loop
 		}
 
-		// save collected chapters
-		db.SaveCollectedChapters()
-		if err := db.Close(); err != nil {
-			log.Error().Err(err).Msg("error closing db connection")
-			os.Exit(1)
+		log.Info().Msg("received SIGHUP, reloading config")
+		changed, err := cfg.Reload(log)
+		if errors.Is(err, domain.ErrConfigInvalid) {
+			log.Warn().Err(err).Msg("config file is invalid, keeping the current config")
+			continue
+		} else if err != nil {
+			log.Error().Err(err).Msg("error reloading config")
+			continue
+		}
+
+		if bot != nil && (slices.Contains(changed, "watchedMangas") || slices.Contains(changed, "guilds")) {
+			if err := bot.RegisterCommands(); err != nil {
+				log.Error().Err(err).Msg("error registering slash commands")
+			}
+		}
+	}
+
+	// cancel in-flight jobs, the scheduler waits for them to finish until the shutdown times out
+	cancel()
+
+	steps := []shutdownStep{
+		{"stopping scheduler", func(ctx context.Context) error { return withContext(ctx, s.Shutdown) }},
+		{"shutting down http server", srv.Shutdown},
+	}
+	// ctx is already cancelled, so saving uses the context of the shutdown
+	if !cfg.Config.DryRun {
+		steps = append(steps, shutdownStep{"saving collected chapters", db.SaveCollectedChapters})
+		steps = append(steps, shutdownStep{"saving bot state", checkErrs.Save})
+	}
+	if bot != nil {
+		steps = append(steps, shutdownStep{"closing discord session", bot.Close})
+	}
+	steps = append(steps, shutdownStep{"closing db connection", func(ctx context.Context) error {
+		return withContext(ctx, db.Close)
+	}})
+
+	shutdownOK := shutdown(log, cfg, steps)
+	sentry.Flush(sentryFlushTimeout)
+	flushTraces(log, shutdownTracing)
+
+	if !shutdownOK {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// logStartup logs a single event with the version and a summary of the config. The config is sanitized first, so no
+// secret can end up in the logs.
+func logStartup(log logger.Logger, cfg *config.AppConfig) {
+	sanitized := cfg.Sanitize()
+
+	log.Info().
+		Str("version", version).
+		Str("commit", commit).
+		Str("buildDate", date).
+		Str("logLevel", sanitized.EffectiveLogLevel()).
+		Int("sleepTimerMinutes", sanitized.SleepTimer).
+		Int("watchedMangaCount", len(sanitized.AllWatchedMangas())).
+		Str("dbPath", filepath.Base(sanitized.CollectedChaptersDB)).
+		Int("discordChannelCount", len(sanitized.DiscordChannels())).
+		Bool("healthCheckEnabled", sanitized.HealthCheckPort != 0).
+		Bool("dryRun", sanitized.DryRun).
+		Bool("watchAll", sanitized.WatchesAll()).
+		Msg("Starting tcb-bot")
+}
+
+// shutdownStep is a single step of the ordered shutdown.
+type shutdownStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// shutdown runs the steps in order within shutdownTimeoutSeconds. Failed steps are logged, once the timeout is
+// reached the remaining steps are logged as aborted. Reports whether every step succeeded.
+func shutdown(log logger.Logger, cfg *config.AppConfig, steps []shutdownStep) bool {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(cfg.Config.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	ok := true
+	for i, step := range steps {
+		if ctx.Err() != nil {
+			for _, aborted := range steps[i:] {
+				log.Error().Msgf("shutdown timed out, aborted %s", aborted.name)
+			}
+			return false
+		}
+
+		if err := step.run(ctx); err != nil {
+			log.Error().Err(err).Msgf("error %s", step.name)
+			ok = false
 		}
+	}
+
+	return ok
+}
+
+// withContext runs fn, which doesn't support contexts, and returns the error of ctx if it's done first. fn keeps
+// running in the background then.
+func withContext(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushTraces sends the pending spans and stops exporting traces.
+func flushTraces(log logger.Logger, shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("error flushing traces")
+	}
+}
+
+// scheduleChecks replaces all check jobs with one job per watched manga that runs in the manga's own
+// sleep timer, falling back to the global one.
+func scheduleChecks(ctx context.Context, s gocron.Scheduler, log logger.Logger, cfg *config.AppConfig, collector *html.Collector,
+	checkErrs *checkErrors) error {
+	s.RemoveByTags(checkJobTag)
+
+	for _, mangaTitle := range cfg.Config.AllWatchedMangas() {
+		sleepTimer := cfg.Config.SleepTimer
+		if mangaSleepTimer, ok := utils.LookupTitle(cfg.Config.MangaSleepTimers, mangaTitle); ok {
+			sleepTimer = mangaSleepTimer
+		}
+
+		_, err := s.NewJob(
+			gocron.CronJob(
+				fmt.Sprintf("*/%d * * * *", sleepTimer),
+				false,
+			),
+			gocron.NewTask(
+				recovered(log, checkErrs.notifier, func() {
+					if checkErrs.InCooldown(mangaTitle) {
+						log.Debug().Msgf("Manga is in error cooldown, skipping check: %q", mangaTitle)
+						return
+					}
+
+					log.Debug().Msgf("Checking for new chapters: %q", mangaTitle)
+
+					// a panic counts as an error of the manga, it's reported by recovered
+					err := errCheckPanicked
+					defer func() { checkErrs.ReportManga(ctx, mangaTitle, err) }()
 
-		// shut down scheduler
-		err = s.Shutdown()
+					_, err = collector.CheckManga(ctx, mangaTitle)
+					if errors.Is(err, html.ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+						return
+					}
+					checkErrs.Report(ctx, mangaTitle, err)
+				}),
+			),
+			gocron.WithTags(checkJobTag),
+		)
 		if err != nil {
-			log.Error().Err(err).Msg("error shutting down scheduler")
-			os.Exit(1)
+			return err
 		}
 
-		os.Exit(0)
+		log.Debug().Msgf("Checking %q every %d minutes", mangaTitle, sleepTimer)
+	}
+
+	return nil
+}
+
+// mangaErrorThreshold is the number of failed checks in a row after which the checks of a manga are skipped
+// for mangaErrorCooldownMinutes
+const mangaErrorThreshold = 3
+
+// errCheckPanicked is reported for checks of a manga that panicked
+var errCheckPanicked = errors.New("check panicked")
+
+// checkErrors remembers the last error of every manga, so the same error is only notified once. The last notified
+// error and the circuit state are kept in bot_state, so an error that was notified before a restart isn't notified
+// again. It also counts the failed checks of every manga in a row.
+type checkErrors struct {
+	m           sync.Mutex
+	log         logger.Logger
+	cfg         *config.AppConfig
+	db          *database.DB
+	notifier    discord.Notifier
+	botState    domain.BotState
+	mangaErrors map[string]*mangaErrorState
+	// failing holds the notified error of every manga whose last check failed
+	failing map[string]string
+	// restoredError is the last error of the previous run, it isn't notified again by the first failed check of a manga
+	restoredError string
+
+	// saveM makes sure the bot state is saved in the order it changes
+	saveM sync.Mutex
+}
+
+// mangaErrorState counts the failed checks of a manga in a row, its checks are skipped until cooldownUntil.
+type mangaErrorState struct {
+	consecutiveErrors int
+	cooldownUntil     time.Time
+}
+
+func newCheckErrors(ctx context.Context, log logger.Logger, cfg *config.AppConfig, db *database.DB,
+	notifier discord.Notifier) *checkErrors {
+	botState, err := db.LoadBotState(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("error loading bot state")
+	}
+
+	return &checkErrors{
+		log:           log,
+		cfg:           cfg,
+		db:            db,
+		notifier:      notifier,
+		botState:      botState,
+		mangaErrors:   make(map[string]*mangaErrorState),
+		failing:       make(map[string]string),
+		restoredError: botState.LastError,
+	}
+}
+
+// CircuitState returns the circuit state saved by the previous run.
+func (e *checkErrors) CircuitState() string {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return e.botState.CircuitState
+}
+
+// SetCircuitState saves the new state of the circuit.
+func (e *checkErrors) SetCircuitState(circuitState string) {
+	e.m.Lock()
+	e.botState.CircuitState = circuitState
+	e.m.Unlock()
+
+	// the circuit changes state at the end of a check, so the context of the check may already be cancelled
+	if err := e.Save(context.Background()); err != nil {
+		e.log.Error().Err(err).Msg("error saving circuit state")
+	}
+}
+
+// Save upserts the bot state.
+func (e *checkErrors) Save(ctx context.Context) error {
+	e.saveM.Lock()
+	defer e.saveM.Unlock()
+
+	e.m.Lock()
+	botState := e.botState
+	e.m.Unlock()
 
-	default:
-		pflag.Usage()
-		if cmd != "help" {
-			os.Exit(0)
+	return e.db.SaveBotState(ctx, botState)
+}
+
+// InCooldown reports whether the checks of the manga are skipped because they failed too often in a row.
+func (e *checkErrors) InCooldown(mangaTitle string) bool {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	errorState, ok := e.mangaErrors[mangaTitle]
+	return ok && time.Now().Before(errorState.cooldownUntil)
+}
+
+// ReportManga counts the failed checks of a manga in a row. After mangaErrorThreshold failed checks, its checks are
+// skipped for mangaErrorCooldownMinutes. Failures of the website itself aren't counted, they open the circuit instead.
+func (e *checkErrors) ReportManga(ctx context.Context, mangaTitle string, err error) {
+	if errors.Is(err, html.ErrCircuitOpen) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, domain.ErrScrapeFailure) {
+		return
+	}
+
+	resumed, paused := e.countManga(mangaTitle, err)
+
+	// notifications are sent without holding the lock, so a slow send doesn't block the checks of other mangas
+	if resumed {
+		e.log.Info().Msgf("Checks of manga resumed after error cooldown: %q", mangaTitle)
+		e.notifier.SendResolvedNotification(ctx, "Checks resumed",
+			fmt.Sprintf("Checking %s succeeded again after its error cooldown.", mangaTitle), "")
+	}
+	if paused {
+		e.log.Warn().Err(err).Msgf("Checks of manga failed %d times in a row, skipping them for %d minutes: %q",
+			mangaErrorThreshold, e.cfg.Config.MangaErrorCooldownMinutes, mangaTitle)
+		e.notifier.SendWarnNotification(ctx, "Checks paused", fmt.Sprintf(
+			"Checking %s failed %d times in a row, skipping it for %d minutes.", mangaTitle, mangaErrorThreshold,
+			e.cfg.Config.MangaErrorCooldownMinutes))
+	}
+}
+
+// countManga updates the error state of a manga with the result of its check. It reports whether the manga resumed
+// after its cooldown and whether its checks were paused for the first time.
+func (e *checkErrors) countManga(mangaTitle string, err error) (resumed bool, paused bool) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	errorState, ok := e.mangaErrors[mangaTitle]
+	if err == nil {
+		delete(e.mangaErrors, mangaTitle)
+		return ok && !errorState.cooldownUntil.IsZero(), false
+	}
+
+	if !ok {
+		errorState = &mangaErrorState{}
+		e.mangaErrors[mangaTitle] = errorState
+	}
+	errorState.consecutiveErrors++
+
+	cooldown := time.Duration(e.cfg.Config.MangaErrorCooldownMinutes) * time.Minute
+	if errorState.consecutiveErrors < mangaErrorThreshold || cooldown <= 0 {
+		return false, false
+	}
+	errorState.cooldownUntil = time.Now().Add(cooldown)
+
+	// a manga that fails again after its cooldown is skipped again without another warning
+	return false, errorState.consecutiveErrors == mangaErrorThreshold
+}
+
+// Report notifies about the result of a check of a manga if it differs from the result of its previous check. The
+// last error is only cleared once no manga is failing anymore.
+func (e *checkErrors) Report(ctx context.Context, mangaTitle string, err error) {
+	if err != nil {
+		e.log.Error().Err(err).Msgf("error collecting chapters: %q", mangaTitle)
+		lastError := fmt.Sprintf("Unexpected error occurred: %v", err)
+
+		e.m.Lock()
+		notified, failing := e.failing[mangaTitle]
+		repeated := notified == lastError || (!failing && lastError == e.restoredError)
+		e.failing[mangaTitle] = lastError
+		if !repeated {
+			e.botState.LastError = lastError
+			e.botState.LastErrorTime = time.Now()
 		}
+		e.m.Unlock()
+		if repeated {
+			return
+		}
+
+		// notifications are sent without holding the lock, so a slow send doesn't block the checks of other mangas
+		e.notifier.SendErrorNotification(ctx, fmt.Sprintf("Error collecting chapters of %s", mangaTitle), lastError, "")
+		e.save(ctx)
+		return
+	}
+
+	e.m.Lock()
+	_, failing := e.failing[mangaTitle]
+	delete(e.failing, mangaTitle)
+	cleared := len(e.failing) == 0 && e.botState.LastError != ""
+	restored := cleared && !failing && e.restoredError != ""
+	if cleared {
+		e.botState.LastError = ""
+		e.botState.LastErrorTime = time.Time{}
+		e.restoredError = ""
 	}
+	e.m.Unlock()
+
+	switch {
+	case failing:
+		e.log.Info().Msgf("error has been resolved: %q", mangaTitle)
+		e.notifier.SendResolvedNotification(ctx, "Error resolved",
+			fmt.Sprintf("The previous error of %s has been resolved", mangaTitle), "")
+	case restored:
+		e.log.Info().Msg("error of the previous run has been resolved")
+		e.notifier.SendResolvedNotification(ctx, "Error resolved", "The previous error has been resolved", "")
+	}
+	if cleared {
+		e.save(ctx)
+	}
+}
+
+func (e *checkErrors) save(ctx context.Context) {
+	if err := e.Save(ctx); err != nil {
+		e.log.Error().Err(err).Msg("error saving bot state")
+	}
+}
+
+// recovered wraps a scheduler task, so a panic inside it is reported instead of crashing the bot.
+func recovered(log logger.Logger, notifier discord.Notifier, task func()) func() {
+	return func() {
+		utils.SafeGo(task, log.With().Str("module", "scheduler").Logger(), notifier)
+	}
+}
+
+// printDBStats prints statistics of the database as tables and exits with code 1 if the integrity check fails.
+func printDBStats(configPath string) {
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	// read-only, so it's safe to inspect the database while tcb-bot is running
+	db := database.NewDB(log, cfg, domain.NewSyncMapStore())
+	if err := db.OpenReadOnly(); err != nil {
+		fmt.Printf("Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	stats, err := db.Stats(context.Background())
+	if err != nil {
+		fmt.Printf("Failed to inspect database: %v\n", err)
+		os.Exit(1)
+	}
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.RFC1123)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Database\t%s\n", cfg.Config.CollectedChaptersDB)
+	fmt.Fprintf(w, "File size\t%d bytes\n", stats.FileSize)
+	fmt.Fprintf(w, "Pages\t%d\n", stats.PageCount)
+	fmt.Fprintf(w, "Free pages\t%d\n", stats.FreePages)
+	fmt.Fprintf(w, "Chapters\t%d\n", stats.Chapters)
+	fmt.Fprintf(w, "Oldest release\t%s\n", formatTime(stats.OldestRelease))
+	fmt.Fprintf(w, "Newest release\t%s\n", formatTime(stats.NewestRelease))
+	fmt.Fprintf(w, "Integrity\t%s\n", stats.Integrity)
+	w.Flush()
+
+	if len(stats.Mangas) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MANGA\tCHAPTERS\tLATEST\tLATEST RELEASE")
+		for _, manga := range stats.Mangas {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", manga.MangaTitle, manga.ChapterCount, manga.LatestChapterNumber,
+				formatTime(manga.LatestChapterTime))
+		}
+		w.Flush()
+	}
+
+	if stats.Integrity != "ok" {
+		os.Exit(1)
+	}
+}
+
+// resetDB deletes the collected chapters of the manga, or all of them if confirmed, so they're announced again.
+func resetDB(configPath string, manga string, confirmed bool) {
+	if manga == "" && !confirmed {
+		fmt.Println("This deletes the chapters of all mangas, pass --confirm to reset them or --manga to reset one manga")
+		os.Exit(1)
+	}
+
+	cfg := config.New(configPath, version)
+	log := newLogger(cfg)
+
+	db := database.NewDB(log, cfg, domain.NewSyncMapStore())
+	if err := db.Open(); err != nil {
+		fmt.Printf("Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	deleted, backupPath, err := db.ResetChapters(context.Background(), manga)
+	if err != nil {
+		fmt.Printf("Failed to reset chapters: %v\n", err)
+		os.Exit(1)
+	}
+	if deleted == 0 {
+		fmt.Println("No chapters to reset")
+		return
+	}
+
+	fmt.Printf("Deleted %d chapters, they're announced again by the next check. A backup was written to %s\n", deleted,
+		backupPath)
+}
+
+// semverTag adds the "v" prefix to a version if it's missing, as required by the semver package.
+func semverTag(version string) string {
+	return "v" + strings.TrimPrefix(version, "v")
+}
+
+// confirm asks the user a yes or no question on stdin, anything but yes counts as no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
@@ -0,0 +1,121 @@
+package anilist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	apiURL = "https://graphql.anilist.co"
+
+	mediaQuery = `query ($search: String) {
+  Media(search: $search, type: MANGA) {
+    status
+    description(asHtml: false)
+    coverImage {
+      large
+    }
+  }
+}`
+)
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+type Client struct {
+	log    zerolog.Logger
+	client *http.Client
+}
+
+func NewClient(log logger.Logger, cfg *config.AppConfig) *Client {
+	return &Client{
+		log:    log.With().Str("module", "anilist").Logger(),
+		client: utils.NewHTTPClient(cfg.Config.ScrapeProxyURL, 10*time.Second),
+	}
+}
+
+type mediaResponse struct {
+	Data struct {
+		Media *struct {
+			Status      string `json:"status"`
+			Description string `json:"description"`
+			CoverImage  struct {
+				Large string `json:"large"`
+			} `json:"coverImage"`
+		} `json:"Media"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GetMangaMetadata searches AniList for the manga and returns its cover, synopsis and status.
+func (c *Client) GetMangaMetadata(ctx context.Context, mangaTitle string) (domain.MangaMetadata, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":     mediaQuery,
+		"variables": map[string]string{"search": mangaTitle},
+	})
+	if err != nil {
+		return domain.MangaMetadata{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return domain.MangaMetadata{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	c.log.Trace().Msgf("Querying AniList for: %q", mangaTitle)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return domain.MangaMetadata{}, errors.Wrap(err, "could not query anilist")
+	}
+	defer resp.Body.Close()
+
+	var media mediaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return domain.MangaMetadata{}, errors.Wrap(err, "could not decode anilist response")
+	}
+
+	if len(media.Errors) > 0 {
+		return domain.MangaMetadata{}, errors.New("anilist returned an error: %s", media.Errors[0].Message)
+	}
+	if resp.StatusCode != http.StatusOK || media.Data.Media == nil {
+		return domain.MangaMetadata{}, errors.New("anilist returned no manga for %q, status code %d", mangaTitle,
+			resp.StatusCode)
+	}
+
+	return domain.MangaMetadata{
+		MangaTitle: mangaTitle,
+		CoverImage: media.Data.Media.CoverImage.Large,
+		Synopsis:   cleanSynopsis(media.Data.Media.Description),
+		Status:     media.Data.Media.Status,
+	}, nil
+}
+
+// cleanSynopsis strips the html tags AniList keeps in descriptions and shortens it to fit
+// into an embed field.
+func cleanSynopsis(description string) string {
+	synopsis := strings.TrimSpace(htmlTagRegex.ReplaceAllString(description, ""))
+
+	const maxLength = 1024
+	if runes := []rune(synopsis); len(runes) > maxLength {
+		synopsis = fmt.Sprintf("%s…", string(runes[:maxLength-1]))
+	}
+
+	return synopsis
+}
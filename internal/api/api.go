@@ -0,0 +1,190 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// CheckFunc runs a single check for new chapters and returns how many were found.
+type CheckFunc func(ctx context.Context) (int, error)
+
+type Handler struct {
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	chapters domain.ChapterStore
+	db       *database.DB
+	check    CheckFunc
+}
+
+func NewHandler(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore, db *database.DB,
+	check CheckFunc) *Handler {
+	return &Handler{
+		log:      log.With().Str("module", "api").Logger(),
+		cfg:      cfg,
+		chapters: chapters,
+		db:       db,
+		check:    check,
+	}
+}
+
+// envelope is the format of every API response.
+type envelope struct {
+	Data  any    `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+type mangaStat struct {
+	MangaTitle          string    `json:"manga_title"`
+	ChapterCount        int       `json:"chapter_count"`
+	FirstChapterTime    time.Time `json:"first_chapter_time"`
+	LatestChapterTime   time.Time `json:"latest_chapter_time"`
+	LatestChapterNumber string    `json:"latest_chapter_number"`
+}
+
+// RegisterRoutes adds all API routes to the mux.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/chapters", h.listChapters)
+	mux.HandleFunc("GET /api/chapters/{manga}", h.listMangaChapters)
+	mux.HandleFunc("GET /api/stats", h.listStats)
+	mux.HandleFunc("POST /api/check", h.authorized(h.runCheck))
+	mux.HandleFunc("DELETE /api/chapters/{releaseTitle}", h.authorized(h.deleteChapter))
+	mux.HandleFunc("GET /logs", h.authorized(h.streamLogs))
+}
+
+func (h *Handler) listChapters(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, http.StatusOK, h.collectedChapters(func(domain.ChapterInfo) bool { return true }))
+}
+
+func (h *Handler) listMangaChapters(w http.ResponseWriter, r *http.Request) {
+	manga := r.PathValue("manga")
+	h.respond(w, http.StatusOK, h.collectedChapters(func(c domain.ChapterInfo) bool {
+		return utils.TitleMatches(c.MangaTitle, manga, false)
+	}))
+}
+
+func (h *Handler) listStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.db.MangaStats(r.Context())
+	if err != nil {
+		h.log.Error().Err(err).Msg("error loading manga stats")
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]mangaStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, mangaStat{
+			MangaTitle:          stat.MangaTitle,
+			ChapterCount:        stat.ChapterCount,
+			FirstChapterTime:    stat.FirstChapterTime.UTC(),
+			LatestChapterTime:   stat.LatestChapterTime.UTC(),
+			LatestChapterNumber: stat.LatestChapterNumber,
+		})
+	}
+
+	h.respond(w, http.StatusOK, result)
+}
+
+func (h *Handler) runCheck(w http.ResponseWriter, r *http.Request) {
+	found, err := h.check(r.Context())
+	if err != nil {
+		h.log.Error().Err(err).Msg("error running check triggered by api")
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]int{"new_chapters": found})
+}
+
+func (h *Handler) deleteChapter(w http.ResponseWriter, r *http.Request) {
+	releaseTitle := r.PathValue("releaseTitle")
+
+	if _, err := h.db.GetChapter(r.Context(), releaseTitle); errors.Is(err, sql.ErrNoRows) {
+		h.respondError(w, http.StatusNotFound, "chapter not found")
+		return
+	} else if err != nil {
+		h.log.Error().Err(err).Msgf("error looking up chapter: %q", releaseTitle)
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.db.DeleteChapter(r.Context(), releaseTitle); err != nil {
+		h.log.Error().Err(err).Msgf("error deleting chapter: %q", releaseTitle)
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.chapters.Delete(releaseTitle)
+
+	h.log.Info().Msgf("Deleted chapter using api: %q", releaseTitle)
+	h.respond(w, http.StatusOK, map[string]string{"deleted": releaseTitle})
+}
+
+// authorized only lets requests through that carry the configured api token. Without a
+// configured token, the endpoint is disabled.
+func (h *Handler) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := h.cfg.Config.APIToken
+		if token == "" {
+			h.respondError(w, http.StatusForbidden, "apiToken is not configured")
+			return
+		}
+
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			h.respondError(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (h *Handler) respond(w http.ResponseWriter, status int, data any) {
+	h.write(w, status, envelope{Data: data})
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	h.write(w, status, envelope{Error: message})
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int, body envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.log.Error().Err(err).Msg("error encoding api response")
+	}
+}
+
+// collectedChapters returns all collected chapters that match the filter, sorted by release title.
+func (h *Handler) collectedChapters(filter func(domain.ChapterInfo) bool) []domain.CollectedChapter {
+	chapters := make([]domain.CollectedChapter, 0)
+	h.chapters.Range(func(releaseTitle string, info domain.ChapterInfo) bool {
+		if !filter(info) {
+			return true
+		}
+
+		chapters = append(chapters, domain.CollectedChapter{ReleaseTitle: releaseTitle, ChapterInfo: info})
+		return true
+	})
+
+	slices.SortFunc(chapters, func(a, b domain.CollectedChapter) int {
+		return cmp.Compare(a.ReleaseTitle, b.ReleaseTitle)
+	})
+
+	return chapters
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"tcb-bot/internal/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	logsWriteTimeout = 10 * time.Second
+	logsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// streamLogs upgrades the connection to a WebSocket and sends every JSON log line as a text message until the
+// client disconnects.
+func (h *Handler) streamLogs(w http.ResponseWriter, r *http.Request) {
+	lines, unsubscribe, err := logger.SubscribeLogs()
+	if errors.Is(err, logger.ErrTooManySubscribers) {
+		h.respondError(w, http.StatusServiceUnavailable, "too many clients are streaming the logs")
+		return
+	}
+	defer unsubscribe()
+
+	// the upgrader responds with an error itself if the upgrade fails
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.log.Debug().Err(err).Msg("error upgrading log stream")
+		return
+	}
+	defer conn.Close()
+
+	h.log.Debug().Msgf("Started streaming logs to %s", r.RemoteAddr)
+
+	// clients don't send anything, but reading is needed to notice that they disconnected
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(logsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			h.log.Debug().Msgf("Stopped streaming logs to %s", r.RemoteAddr)
+			return
+		case line := <-lines:
+			if err := conn.SetWriteDeadline(time.Now().Add(logsWriteTimeout)); err != nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, line); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(logsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
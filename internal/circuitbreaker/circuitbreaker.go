@@ -0,0 +1,159 @@
+// Package circuitbreaker stops requests to a failing endpoint for a while, so it isn't hammered
+// with requests that are going to fail anyway.
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	// Closed lets all requests through.
+	Closed State = iota
+	// Open rejects all requests until the reset timeout has passed.
+	Open
+	// HalfOpen lets a single probe request through, its result decides whether the circuit closes again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseState parses the result of State.String.
+func ParseState(s string) (State, bool) {
+	for _, state := range []State{Closed, Open, HalfOpen} {
+		if state.String() == s {
+			return state, true
+		}
+	}
+
+	return Closed, false
+}
+
+type CircuitBreaker struct {
+	m             sync.Mutex
+	state         State
+	failures      int
+	threshold     int
+	resetTimeout  time.Duration
+	openedAt      time.Time
+	onStateChange func(State)
+}
+
+// New returns a closed circuit breaker that opens after threshold consecutive failures.
+func New(threshold int, resetTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+	}
+}
+
+// OnStateChange sets fn to be called with the new state whenever the state changes. fn is called with the lock held,
+// so changes are reported in order, it must not call the circuit breaker.
+func (cb *CircuitBreaker) OnStateChange(fn func(State)) {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	cb.onStateChange = fn
+}
+
+// Restore sets the state saved by an earlier run. The time an open circuit was opened at isn't saved, so it's
+// restored with its reset timeout passed: the next request probes the endpoint and a failed probe opens the circuit
+// again without being reported by Failure.
+func (cb *CircuitBreaker) Restore(state State) {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	if state == Closed {
+		cb.failures = 0
+		cb.setState(Closed)
+		return
+	}
+
+	cb.failures = cb.threshold
+	cb.openedAt = time.Now().Add(-cb.resetTimeout)
+	cb.setState(Open)
+}
+
+// setState changes the state and reports the change, the lock must be held.
+func (cb *CircuitBreaker) setState(state State) {
+	if cb.state == state {
+		return
+	}
+
+	cb.state = state
+	if cb.onStateChange != nil {
+		cb.onStateChange(state)
+	}
+}
+
+// Allow reports whether a request may be made. Once the reset timeout has passed, an open circuit
+// becomes half-open and allows a single probe request.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	switch cb.state {
+	case Closed:
+		return true
+	case Open:
+		if time.Since(cb.openedAt) < cb.resetTimeout {
+			return false
+		}
+		cb.setState(HalfOpen)
+		return true
+	default:
+		// the probe request is still running
+		return false
+	}
+}
+
+// Success records a successful request and closes the circuit.
+func (cb *CircuitBreaker) Success() {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	cb.failures = 0
+	cb.setState(Closed)
+}
+
+// Failure records a failed request and reports whether it opened a previously closed circuit.
+// A failed probe request opens the circuit again without being reported.
+func (cb *CircuitBreaker) Failure() bool {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	cb.failures++
+
+	switch {
+	case cb.state == HalfOpen:
+		cb.openedAt = time.Now()
+		cb.setState(Open)
+		return false
+	case cb.state == Closed && cb.failures >= cb.threshold:
+		cb.openedAt = time.Now()
+		cb.setState(Open)
+		return true
+	default:
+		return false
+	}
+}
+
+func (cb *CircuitBreaker) State() State {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	return cb.state
+}
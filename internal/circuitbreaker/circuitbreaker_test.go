@@ -0,0 +1,76 @@
+package circuitbreaker
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStateChanges(t *testing.T) {
+	cb := New(2, time.Hour)
+	var changes []State
+	cb.OnStateChange(func(state State) { changes = append(changes, state) })
+
+	cb.Failure()
+	if !cb.Failure() {
+		t.Error("Failure() didn't report that the circuit opened")
+	}
+	cb.Failure()
+	cb.Success()
+	cb.Success()
+
+	if want := []State{Open, Closed}; !slices.Equal(changes, want) {
+		t.Errorf("state changes = %v, want %v", changes, want)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		// probeFails makes the request allowed after restoring fail
+		probeFails bool
+		want       State
+		wantOpened bool
+	}{
+		{name: "closed", state: "closed", want: Closed},
+		{name: "open probe succeeds", state: "open", want: Closed},
+		{name: "open probe fails", state: "open", probeFails: true, want: Open},
+		{name: "half-open probe fails", state: "half-open", probeFails: true, want: Open},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, ok := ParseState(tt.state)
+			if !ok {
+				t.Fatalf("ParseState(%q) didn't parse the state", tt.state)
+			}
+
+			cb := New(2, time.Hour)
+			cb.Restore(state)
+			if !cb.Allow() {
+				t.Fatal("Allow() = false after restoring, want a request to be allowed")
+			}
+
+			opened := false
+			if tt.probeFails {
+				opened = cb.Failure()
+			} else {
+				cb.Success()
+			}
+
+			if got := cb.State(); got != tt.want {
+				t.Errorf("State() = %v, want %v", got, tt.want)
+			}
+			if opened != tt.wantOpened {
+				t.Errorf("Failure() = %v, want %v, a restored circuit mustn't be reported as opened again", opened, tt.wantOpened)
+			}
+		})
+	}
+}
+
+func TestParseStateUnknown(t *testing.T) {
+	if _, ok := ParseState(""); ok {
+		t.Error(`ParseState("") parsed an empty state`)
+	}
+}
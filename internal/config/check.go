@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"text/template"
+
+	"github.com/spf13/viper"
+)
+
+// discordTokenRegex matches the three base64 parts of a Discord bot token
+var discordTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`)
+
+// Check loads the config file without creating or updating it and returns every problem it found.
+// Besides ValidateConfig, it checks that all paths are writable and that the discordToken looks valid.
+func Check(configPath string) error {
+	c := &AppConfig{
+		m: new(sync.Mutex),
+	}
+	c.defaults()
+
+	setConfigFile(path.Clean(configPath))
+	if err := c.readConfig(); err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	c.bindEnv()
+	if err := unmarshal(c.Config); err != nil {
+		return fmt.Errorf("could not unmarshal config file %s: %w", viper.ConfigFileUsed(), err)
+	}
+	c.loadMangaColors()
+
+	var errs []error
+	if err := ValidateConfig(c.Config); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := template.New("notification").Parse(c.Config.NotificationTemplate); err != nil {
+		errs = append(errs, fmt.Errorf("notificationTemplate: %w", err))
+	}
+
+	for _, file := range [][2]string{
+		{"collectedChaptersDB", c.Config.CollectedChaptersDB},
+		{"logPath", c.Config.LogPath},
+	} {
+		// missing directories are created on startup, inside the closest one that exists
+		if dir := existingDir(filepath.Dir(file[1])); file[1] != "" && !dirWritable(dir) {
+			errs = append(errs, fmt.Errorf("%s: directory %q is not writable", file[0], dir))
+		}
+	}
+
+	if c.Config.DiscordToken != "" && !discordTokenRegex.MatchString(c.Config.DiscordToken) {
+		errs = append(errs, errors.New("discordToken: doesn't look like a Discord bot token"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// FormatCheckError prints every error returned by Check on its own line.
+func FormatCheckError(err error) string {
+	return formatValidationError(err)
+}
+
+// existingDir returns dir or its closest parent directory that exists.
+func existingDir(dir string) string {
+	for !dirExists(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return dir
+}
+
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".tcb-bot-check-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return true
+}
@@ -5,21 +5,30 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"text/template"
+	"unicode"
 
 	"tcb-bot/internal/domain"
 	"tcb-bot/internal/logger"
 
 	"github.com/autobrr/autobrr/pkg/errors"
 	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
+	"go.mozilla.org/sops/v3/decrypt"
 )
 
 var configTemplate = `# config.toml
@@ -36,6 +45,128 @@ discordToken = ""
 #
 discordChannelID = ""
 
+# Discord Webhook URL
+# Used instead of the bot session if no discordToken is set
+#
+# Optional
+#
+#discordWebhookURL = ""
+
+# Discord Hiatus Channel ID
+# Channel for hiatus notifications, falls back to discordChannelID
+#
+# Optional
+#
+#discordHiatusChannelID = ""
+
+# Discord Forum Channel ID
+# Create a thread per chapter in this forum channel instead of sending it to discordChannelID
+#
+# Optional
+#
+#discordForumChannelID = ""
+
+# Discord Error Channel ID
+# Channel for error notifications, falls back to discordChannelID
+#
+# Optional
+#
+#discordErrorChannelID = ""
+
+# Discord Warn Channel ID
+# Channel for non-critical issues like chapter gaps, falls back to discordErrorChannelID
+#
+# Optional
+#
+#discordWarnChannelID = ""
+
+# Discord Critical Channel ID
+# Channel for critical issues like a lost Discord session or an unreachable database, falls back to discordErrorChannelID
+#
+# Optional
+#
+#discordCriticalChannelID = ""
+
+# Discord rate limit
+# Maximum number of messages sent to Discord per second
+#
+# Default: 5
+#
+#discordRateLimit = 5
+
+# Discord footer icon URL
+# Icon shown next to the footer of chapter notifications
+#
+# Default: "" (the TCB Scans favicon)
+#
+#discordFooterIconURL = ""
+
+# Discord embed author
+# Name, link and icon shown above the title of chapter notifications, e.g. to brand them for your server.
+# The link and icon are only shown if a name is set. Error notifications always show tcb-bot as their author.
+#
+# Optional
+#
+#discordEmbedAuthorName = ""
+#discordEmbedAuthorURL = ""
+#discordEmbedAuthorIconURL = ""
+
+# Discord react emoji enabled
+# React to chapter notifications with discordReactEmoji, only supported by the bot
+#
+# Default: false
+#
+#discordReactEmojiEnabled = false
+
+# Discord react emoji
+# Emoji the bot reacts with to its chapter notifications, custom server emojis use the name:id format
+#
+# Default: ""
+#
+# Example: "📖" or "tcb:123456789012345678"
+#
+#discordReactEmoji = ""
+
+# Notifier
+# Where notifications are sent
+#
+# Default: "discord"
+#
+# Options: "discord", "telegram", "both", "smtp"
+#
+#notifier = "discord"
+
+# Notification style
+# "plain" sends notifications as plain text messages instead of embeds, e.g. for webhooks that don't support embeds.
+# Plain chapter notifications have no "Mark as Read" button
+#
+# Default: "embed"
+#
+# Options: "embed", "plain"
+#
+#notificationStyle = "embed"
+
+# Telegram Bot Token
+# Required if the notifier is "telegram" or "both"
+#
+# Optional
+#
+#telegramBotToken = ""
+
+# Telegram Chat ID
+# Required if the notifier is "telegram" or "both"
+#
+# Optional
+#
+#telegramChatID = ""
+
+# Telegram Error Chat ID
+# Chat for error and resolved notifications, falls back to telegramChatID
+#
+# Optional
+#
+#telegramErrorChatID = ""
+
 # Collected Chapters Database File
 # Make sure to use forward slashes and include the filename with extension. e.g. "database/collected_chapters.db"
 #
@@ -43,6 +174,14 @@ discordChannelID = ""
 #
 collectedChaptersDB = ""
 
+# Database connections
+# Maximum number of open and idle database connections, SQLite only supports a single writer
+#
+# Default: 1
+#
+#dbMaxOpenConns = 1
+#dbMaxIdleConns = 1
+
 # tcb-bot logs file
 # If not defined, logs to stdout
 # Make sure to use forward slashes and include the filename with extension. e.g. "logs/tcb-bot.log", "C:/tcb-bot/logs/tcb-bot.log"
@@ -59,6 +198,15 @@ collectedChaptersDB = ""
 #
 logLevel = "DEBUG"
 
+# Log format
+# Format of the logs written to stderr, the log file always uses JSON
+#
+# Default: "json"
+#
+# Options: "json", "console"
+#
+#logFormat = "json"
+
 # Log Max Size
 #
 # Default: 50
@@ -75,17 +223,365 @@ logLevel = "DEBUG"
 #
 #logMaxBackups = 3
 
+# Log Rotate Daily
+#
+# Default: false
+#
+# Start a new log file every day, the date is appended to its name, e.g. tcb-bot-2006-01-02.log.
+# Log files are still rotated once they reach logMaxSize. Only the log files of the last logMaxBackups days are kept.
+#
+#logRotateDaily = false
+
 # Watched Mangas
 #
 # Default: [ "One Piece", "Jujutsu Kaisen" ]
 #
 #watchedMangas = [ "One Piece", "Jujutsu Kaisen" ]
 
+# Watch all mangas
+# Announce every chapter released on the site instead of only the watched mangas, same as watchedMangas = [ "*" ]
+# This can send a lot of notifications, sleepTimer defaults to 60 if it's enabled
+#
+# Default: false
+#
+#watchAll = false
+
+# Manga channels
+# Send notifications of a manga to a different channel than discordChannelID
+#
+# Optional
+#
+#mangaChannels = { "One Piece" = "123456789012345678" }
+
+# Manga roles
+# Ping a role with every chapter notification of a manga, @everyone and @here are never pinged
+#
+# Optional
+#
+#mangaRoles = { "One Piece" = "123456789012345678" }
+
+# Manga message prefix and suffix
+# Text above the embed of the Discord notifications of a manga, before and after the ping of its role. Mention a
+# role using <@&role ID> to ping it, @everyone and @here are rejected
+#
+# Optional
+#
+#mangaMessagePrefix = { "One Piece" = "🏴‍☠️ NEW CHAPTER ALERT:" }
+#mangaMessageSuffix = { "One Piece" = "||Spoilers ahead||" }
+
+# Manga ping cooldown in minutes
+# Only ping the role of a manga once within the cooldown, chapters released in between are announced without a ping
+#
+# Default: 0 (no cooldown)
+#
+#mangaPingCooldownMinutes = 0
+
+# Manga error cooldown in minutes
+# Skip the checks of a manga for this long after they failed 3 times in a row, e.g. because of a panic. Failures of the
+# website itself pause all checks using the circuit breaker instead
+#
+# Default: 60 (0 to never skip checks)
+#
+#mangaErrorCooldownMinutes = 60
+
+# Max notifications per run
+# Only announce this many chapters per check, e.g. after watching a manga with many chapters on the website.
+# The other chapters are announced by the next checks
+#
+# Default: 0 (unlimited)
+#
+#maxNotificationsPerRun = 0
+
+# Manga start chapter
+# Only announce chapters of a manga starting from this chapter, earlier chapters are skipped
+#
+# Optional
+#
+#mangaStartChapter = { "One Piece" = "1100" }
+
+# Manga end chapter
+# Stop announcing chapters of a manga after this chapter, later chapters are still collected
+#
+# Optional
+#
+#mangaEndChapter = { "One Piece" = "1200" }
+
+# Manga aliases
+# Other titles the site uses for a manga, chapters released under an alias are treated as chapters of the manga
+#
+# Optional
+#
+#mangaAliases = { "Jujutsu Kaisen" = ["Jujutsu Kaisen (Official)"] }
+
+# Fuzzy match
+# Also match manga titles that are up to two typos away from a watched manga
+#
+# Default: false
+#
+#fuzzyMatch = false
+
 # Sleep timer in minutes
 #
-# Default: 15
+# Default: 15, 60 if all mangas are watched
 #
 #sleepTimer = 15
+
+# Manga sleep timers
+# Check a manga in a different interval than sleepTimer, in minutes
+#
+# Optional
+#
+#mangaSleepTimers = { "One Piece" = 5 }
+
+# Check on startup
+# Check for new chapters right after starting, so chapters released while the bot was offline are announced
+# without waiting for the first sleep timer
+#
+# Default: true
+#
+#checkOnStartup = true
+
+# Allow URL revisit
+# Disable to skip pages that were already visited during the current session
+#
+# Default: true
+#
+#allowURLRevisit = true
+
+# Scrape URLs
+# URLs of TCB Scans and its mirrors, if one is unreachable the next one is tried
+#
+# Default: [ "https://tcbscans.me" ]
+#
+#scrapeURLs = [ "https://tcbscans.me" ]
+
+# Scrape proxy
+# Route all requests to TCB Scans and AniList through a proxy, supports http://, https:// and socks5://
+#
+# Optional
+#
+#scrapeProxyURL = ""
+
+# Scrape timeout in seconds
+# Maximum time a single request to TCB Scans may take
+#
+# Default: 60
+#
+#scrapeTimeoutSeconds = 60
+
+# Scrape max body size in bytes
+# Responses of TCB Scans are cut off after this many bytes
+#
+# Default: 10485760 (10 MB)
+#
+#scrapeMaxBodyBytes = 10485760
+
+# Scrape cache
+# Cache the pages of TCB Scans and only download them again if they were modified, using their ETag and
+# Last-Modified headers. Pages that can't be revalidated or use Cache-Control: no-store are never cached.
+#
+# Default: false
+#
+#cacheEnabled = false
+
+# Scrape cache path
+# Directory the cached pages are stored in, it's created if it doesn't exist. Required if cacheEnabled is true.
+#
+# Optional
+#
+#scrapeCachePath = ""
+
+# Scrape mode
+# Check the website of TCB Scans, the RSS or Atom feed at rssFeedURL or both for new chapters
+#
+# Default: "html"
+#
+# Options: "html", "rss", "both"
+#
+#scrapeMode = "html"
+
+# RSS feed URL
+# RSS or Atom feed that is checked for new chapters if scrapeMode is "rss" or "both"
+#
+# Optional
+#
+#rssFeedURL = ""
+
+# Circuit reset in seconds
+# After 3 failed checks in a row, checks are skipped for this long before trying again
+#
+# Default: 300
+#
+#circuitResetSeconds = 300
+
+# User agents
+# A random user agent of this list is used for every request, the Googlebot user agent is used if empty
+#
+# Default: five recent Chrome and Firefox user agents
+#
+#userAgents = [
+#  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+#  "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+#  "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+#  "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+#  "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+#]
+
+# Scrape headers
+# Added to every request to TCB Scans, e.g. for proxies that require specific headers
+# Content-Type and User-Agent are managed by tcb-bot and can't be set here, use userAgents instead
+#
+# Optional
+#
+#scrapeHeaders = { "Accept-Language" = "en-US,en;q=0.9" }
+
+# HTTP server port
+# Serves the health check at /healthz, the feed at /feed.xml, metrics at /metrics and the REST API at /api
+# Set to 0 to disable
+#
+# Default: 8080
+#
+#healthCheckPort = 8080
+
+# Shutdown timeout in seconds
+# How long running checks, sending notifications and saving the chapters may take on shutdown. Whatever isn't done by
+# then is aborted and tcb-bot exits with code 1
+#
+# Default: 10
+#
+#shutdownTimeoutSeconds = 10
+
+# API token
+# Bearer token required by the write endpoints of the REST API, they are disabled if not set
+#
+# Optional
+#
+#apiToken = ""
+
+# Sentry DSN
+# Report errors and panics to Sentry
+#
+# Optional
+#
+#sentryDSN = ""
+
+# OpenTelemetry endpoint
+# Export traces of every check to this OTLP/HTTP endpoint, e.g. "http://localhost:4318"
+#
+# Optional
+#
+#otelEndpoint = ""
+
+# OpenTelemetry service name
+# Service name of the exported traces
+#
+# Default: "tcb-bot"
+#
+#otelServiceName = "tcb-bot"
+
+# Display time zone
+# Time zone of the release times shown in notifications, e.g. "America/New_York"
+#
+# Default: "UTC"
+#
+#displayTimezone = "UTC"
+
+# Quiet hours
+# Chapter notifications found within this window are sent once it ends
+# Use 24-hour times, e.g. "23:00" and "07:00"
+#
+# Optional
+#
+#quietHoursStart = ""
+#quietHoursEnd = ""
+
+# Quiet hours time zone
+#
+# Default: "Europe/Berlin"
+#
+#quietHoursTZ = "Europe/Berlin"
+
+# Hiatus threshold in days
+# Watched mangas without a new chapter for this long are announced as on hiatus
+# Only mangas that are watched by their title are checked, watchAll and "*" don't enable hiatus notifications
+#
+# Default: 30
+#
+#hiatusThresholdDays = 30
+
+# Milestones
+# Chapters with these numbers get a special notification
+#
+# Optional
+#
+#milestones = [ 100, 500, 1000 ]
+
+# Manga colors
+# Embed color per manga, mangas without a color use the default blue
+#
+# Optional
+#
+#[mangaColors]
+#"One Piece" = 0xF4A030
+#"Jujutsu Kaisen" = 0x6A0DAD
+
+# Manga covers
+# Thumbnail of the chapter notifications per manga, can also be set with the /cover set command
+#
+# Optional
+#
+#mangaCovers = { "One Piece" = "https://example.com/one-piece.jpg" }
+
+# Manga banners
+# Full-width image at the bottom of the chapter notifications per manga
+#
+# Optional
+#
+#mangaBanners = { "One Piece" = "https://example.com/one-piece-banner.jpg" }
+
+# Enrich from AniList
+# Add cover, synopsis and status from AniList to chapter notifications
+#
+# Default: false
+#
+#enrichFromAniList = false
+
+# Notification template
+# Go text/template for the description of chapter notifications
+# Variables: {{.MangaTitle}}, {{.ChapterNumber}}, {{.ChapterTitle}}, {{.ReleaseTime}}, {{.ReleaseLink}}
+#
+# Default: "Chapter {{.ChapterNumber}}{{if .ChapterTitle}}: {{.ChapterTitle}}{{end}}\n"
+#
+#notificationTemplate = "Chapter {{.ChapterNumber}}{{if .ChapterTitle}}: {{.ChapterTitle}}{{end}}\n"
+
+# SMTP
+# Send notifications as emails, required if the notifier is "smtp"
+# Port 465 uses implicit TLS, other ports use STARTTLS if the server supports it
+# Every option below a [smtp] line belongs to it, keep it below all options that aren't part of a table
+#
+# Optional
+#
+#[smtp]
+#host = "smtp.example.com"
+#port = 587
+#username = ""
+#password = ""
+#from = "tcb-bot@example.com"
+#to = [ "me@example.com" ]
+
+# Guilds
+# Send notifications to multiple Discord servers, each with its own channels and watched mangas
+# discordChannelID and watchedMangas are only used if no guilds are configured, not supported with discordWebhookURL
+# Slash commands are registered in every guild with a guildID
+# Keep this at the end of the file, every option below a [[guilds]] line belongs to that guild
+#
+# Optional
+#
+#[[guilds]]
+#guildID = "123456789012345678"
+#discordChannelID = "123456789012345678"
+#discordErrorChannelID = ""
+#watchedMangas = [ "One Piece" ]
 `
 
 func (c *AppConfig) writeConfig(configPath string, configFile string) error {
@@ -127,12 +623,31 @@ type Config interface {
 	DynamicReload(log logger.Logger)
 }
 
+// defaultNotificationTemplate is the description of chapter notifications if no template is configured.
+const defaultNotificationTemplate = "Chapter {{.ChapterNumber}}{{if .ChapterTitle}}: {{.ChapterTitle}}{{end}}\n"
+
+// watchAllSleepTimer is the default sleep timer in minutes if all mangas are watched.
+const watchAllSleepTimer = 60
+
 type AppConfig struct {
-	Config *domain.Config
-	m      *sync.Mutex
+	Config               *domain.Config
+	m                    *sync.Mutex
+	encrypted            bool
+	reloadHooks          []func()
+	notificationTemplate *template.Template
 }
 
 func New(configPath string, version string) *AppConfig {
+	return newConfig(configPath, version, ValidateConfig)
+}
+
+// NewScrapeConfig loads the config like New, but doesn't require a notifier to be configured. It's meant for commands
+// that only scrape the website.
+func NewScrapeConfig(configPath string, version string) *AppConfig {
+	return newConfig(configPath, version, ValidateScrapeConfig)
+}
+
+func newConfig(configPath string, version string, validate func(*domain.Config) error) *AppConfig {
 	c := &AppConfig{
 		m: new(sync.Mutex),
 	}
@@ -141,73 +656,208 @@ func New(configPath string, version string) *AppConfig {
 	c.Config.ConfigPath = configPath
 
 	c.load(configPath)
-	c.loadFromEnv()
 
-	if c.Config.DiscordToken == "" || c.Config.DiscordChannelID == "" || c.Config.CollectedChaptersDB == "" {
-		log.Fatal("discordToken, discordChannelID & collectedChaptersDB must be provided in the config.toml file.")
+	if err := validate(c.Config); err != nil {
+		log.Fatalf("invalid config.toml:\n%s", formatValidationError(err))
+	}
+
+	tmpl, err := template.New("notification").Parse(c.Config.NotificationTemplate)
+	if err != nil {
+		log.Fatalf("notificationTemplate must be a valid template: %q", err)
 	}
+	c.notificationTemplate = tmpl
 
 	return c
 }
 
+// NewDefault returns a config with the default values and the overrides applied, without reading config.toml or the
+// environment. It's validated like config.toml.
+func NewDefault(overrides ...func(*domain.Config)) (*AppConfig, error) {
+	c := &AppConfig{
+		m: new(sync.Mutex),
+	}
+	c.defaults()
+	for _, override := range overrides {
+		override(c.Config)
+	}
+
+	if err := ValidateConfig(c.Config); err != nil {
+		return nil, errors.New("invalid config:\n%s", formatValidationError(err))
+	}
+
+	tmpl, err := template.New("notification").Parse(c.Config.NotificationTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "notificationTemplate must be a valid template")
+	}
+	c.notificationTemplate = tmpl
+
+	return c, nil
+}
+
 func (c *AppConfig) defaults() {
 	c.Config = &domain.Config{
-		DiscordToken:        "",
-		DiscordChannelID:    "",
-		CollectedChaptersDB: "",
-		LogLevel:            "DEBUG",
-		LogPath:             "",
-		LogMaxSize:          50,
-		LogMaxBackups:       3,
-		WatchedMangas:       []string{"One Piece", "Jujutsu Kaisen"},
-		SleepTimer:          15,
-	}
-}
-
-func (c *AppConfig) loadFromEnv() {
-	prefix := "TCB_BOT__"
-
-	envs := os.Environ()
-	for _, env := range envs {
-		if strings.HasPrefix(env, prefix) {
-			envPair := strings.SplitN(env, "=", 2)
-
-			if envPair[1] != "" {
-				switch envPair[0] {
-				case prefix + "DISCORD_TOKEN":
-					c.Config.DiscordToken = envPair[1]
-				case prefix + "DISCORD_CHANNEL_ID":
-					c.Config.DiscordChannelID = envPair[1]
-				case prefix + "COLLECTED_CHAPTERS_DB":
-					c.Config.CollectedChaptersDB = envPair[1]
-				case prefix + "LOG_LEVEL":
-					c.Config.LogLevel = envPair[1]
-				case prefix + "LOG_PATH":
-					c.Config.LogPath = envPair[1]
-				case prefix + "LOG_MAX_SIZE":
-					if i, _ := strconv.ParseInt(envPair[1], 10, 32); i > 0 {
-						c.Config.LogMaxSize = int(i)
-					}
-				case prefix + "LOG_MAX_BACKUPS":
-					if i, _ := strconv.ParseInt(envPair[1], 10, 32); i > 0 {
-						c.Config.LogMaxBackups = int(i)
-					}
-				case prefix + "WATCHED_MANGAS":
-					mangaNames := strings.Split(envPair[1], ",")
-					c.Config.WatchedMangas = mangaNames
-				case prefix + "SLEEP_TIMER":
-					if i, _ := strconv.ParseInt(envPair[1], 10, 32); i > 0 {
-						c.Config.SleepTimer = int(i)
-					}
-				}
+		DiscordToken:              "",
+		DiscordChannelID:          "",
+		DiscordWebhookURL:         "",
+		DiscordHiatusChannelID:    "",
+		DiscordForumChannelID:     "",
+		DiscordErrorChannelID:     "",
+		DiscordWarnChannelID:      "",
+		DiscordCriticalChannelID:  "",
+		DiscordRateLimit:          5,
+		DiscordFooterIconURL:      "",
+		DiscordEmbedAuthorName:    "",
+		DiscordEmbedAuthorURL:     "",
+		DiscordEmbedAuthorIconURL: "",
+		DiscordReactEmojiEnabled:  false,
+		DiscordReactEmoji:         "",
+		Notifier:                  "discord",
+		NotificationStyle:         domain.NotificationStyleEmbed,
+		TelegramBotToken:          "",
+		TelegramChatID:            "",
+		TelegramErrorChatID:       "",
+		CollectedChaptersDB:       "",
+		DBMaxOpenConns:            1,
+		DBMaxIdleConns:            1,
+		LogLevel:                  "DEBUG",
+		LogFormat:                 "json",
+		LogPath:                   "",
+		LogMaxSize:                50,
+		LogMaxBackups:             3,
+		LogRotateDaily:            false,
+		WatchedMangas:             []string{"One Piece", "Jujutsu Kaisen"},
+		WatchAll:                  false,
+		MangaPingCooldownMinutes:  0,
+		MangaErrorCooldownMinutes: 60,
+		MaxNotificationsPerRun:    0,
+		ShutdownTimeoutSeconds:    10,
+		MangaStartChapter:         map[string]string{},
+		MangaEndChapter:           map[string]string{},
+		MangaChannels:             map[string]string{},
+		MangaRoles:                map[string]string{},
+		MangaMessagePrefix:        map[string]string{},
+		MangaMessageSuffix:        map[string]string{},
+		MangaAliases:              map[string][]string{},
+		MangaSleepTimers:          map[string]int{},
+		FuzzyMatch:                false,
+		SleepTimer:                15,
+		CheckOnStartup:            true,
+		AllowURLRevisit:           true,
+		ScrapeURLs:                []string{"https://tcbscans.me"},
+		ScrapeTimeoutSeconds:      60,
+		ScrapeMaxBodyBytes:        10 * 1024 * 1024,
+		ScrapeMode:                "html",
+		CacheEnabled:              false,
+		ScrapeCachePath:           "",
+		RSSFeedURL:                "",
+		CircuitResetSeconds:       300,
+		UserAgents: []string{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+		},
+		ScrapeHeaders:        map[string]string{},
+		HealthCheckPort:      8080,
+		APIToken:             "",
+		SentryDSN:            "",
+		OtelEndpoint:         "",
+		OtelServiceName:      "tcb-bot",
+		DisplayTimezone:      "UTC",
+		QuietHoursStart:      "",
+		QuietHoursEnd:        "",
+		QuietHoursTZ:         "Europe/Berlin",
+		HiatusThresholdDays:  30,
+		Milestones:           []int{},
+		MangaColors:          map[string]int{},
+		MangaCovers:          map[string]string{},
+		MangaBanners:         map[string]string{},
+		SMTP:                 domain.SMTPConfig{Port: 587, To: []string{}},
+		Guilds:               []domain.GuildConfig{},
+		EnrichFromAniList:    false,
+		NotificationTemplate: defaultNotificationTemplate,
+	}
+}
+
+// envPrefix is joined with the environment variable names by viper using an underscore,
+// e.g. TCB_BOT__SLEEP_TIMER
+const envPrefix = "TCB_BOT_"
+
+// bindEnv makes every config field settable using an environment variable that is named after
+// its toml key, e.g. sleepTimer can be set using TCB_BOT__SLEEP_TIMER. Fields with an env tag
+// use that name instead.
+func (c *AppConfig) bindEnv() {
+	replacements := bindFields(reflect.ValueOf(c.Config).Elem(), "", "")
+
+	// replace longer keys first, so keys that are a prefix of another key don't clash
+	slices.SortFunc(replacements, func(a, b [2]string) int {
+		return len(b[0]) - len(a[0])
+	})
+
+	var oldnew []string
+	for _, replacement := range replacements {
+		oldnew = append(oldnew, replacement[0], replacement[1])
+	}
+
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(oldnew...))
+	viper.AutomaticEnv()
+}
+
+// bindFields sets a default for every field of the struct and returns the replacements of their keys with their
+// environment variable names. Fields of tables are prefixed with the table, e.g. TCB_BOT__SMTP_HOST.
+func bindFields(value reflect.Value, keyPrefix string, namePrefix string) [][2]string {
+	var replacements [][2]string
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		key := field.Tag.Get("toml")
+		if key == "" || field.Tag.Get("mapstructure") == "-" {
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			name = envName(key)
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			replacements = append(replacements, bindFields(value.Field(i), keyPrefix+key+".", namePrefix+name+"_")...)
+			continue
+		}
+
+		// viper only looks up environment variables for keys it knows about
+		viper.SetDefault(keyPrefix+key, value.Field(i).Interface())
+
+		replacements = append(replacements, [2]string{strings.ToUpper(envPrefix + "_" + keyPrefix + key),
+			envPrefix + "_" + namePrefix + name})
+	}
+
+	return replacements
+}
+
+// envName turns a camel case toml key into an upper snake case environment variable name,
+// e.g. discordChannelID becomes DISCORD_CHANNEL_ID.
+func envName(key string) string {
+	runes := []rune(key)
+
+	var name strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				name.WriteRune('_')
 			}
 		}
+		name.WriteRune(unicode.ToUpper(r))
 	}
+
+	return name.String()
 }
 
 func (c *AppConfig) load(configPath string) {
-	viper.SetConfigType("toml")
-
 	// clean trailing slash from configPath
 	configPath = path.Clean(configPath)
 	if configPath != "" {
@@ -216,7 +866,29 @@ func (c *AppConfig) load(configPath string) {
 		if err := c.writeConfig(configPath, "config.toml"); err != nil {
 			log.Printf("write error: %q", err)
 		}
+	}
+
+	setConfigFile(configPath)
 
+	// read config
+	if err := c.readConfig(); err != nil {
+		log.Printf("config read error: %q", err)
+	}
+
+	c.bindEnv()
+
+	if err := unmarshal(c.Config); err != nil {
+		log.Fatalf("Could not unmarshal config file: %v: err %q", viper.ConfigFileUsed(), err)
+	}
+
+	c.loadMangaColors()
+}
+
+// setConfigFile makes viper read config.toml from configPath or search the default directories for it.
+func setConfigFile(configPath string) {
+	viper.SetConfigType("toml")
+
+	if configPath != "" {
 		viper.SetConfigFile(path.Join(configPath, "config.toml"))
 	} else {
 		viper.SetConfigName("config")
@@ -226,24 +898,135 @@ func (c *AppConfig) load(configPath string) {
 		viper.AddConfigPath("$HOME/.config/tcb-bot")
 		viper.AddConfigPath("$HOME/.tcb-bot")
 	}
+}
 
-	// read config
-	if err := viper.ReadInConfig(); err != nil {
-		log.Printf("config read error: %q", err)
+// unmarshal decodes the viper settings into cfg. Lists and maps from the config file replace the
+// defaults instead of being merged into them.
+func unmarshal(cfg *domain.Config) error {
+	if err := viper.Unmarshal(cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.ZeroFields = true
+	}); err != nil {
+		return err
 	}
 
-	if err := viper.Unmarshal(c.Config); err != nil {
-		log.Fatalf("Could not unmarshal config file: %v: err %q", viper.ConfigFileUsed(), err)
+	// checking every manga on the site is checked less often, unless the sleep timer is set explicitly
+	if cfg.WatchesAll() && !isConfigured("sleepTimer") {
+		cfg.SleepTimer = watchAllSleepTimer
+	}
+
+	return nil
+}
+
+// isConfigured reports whether the key is set in the config file or using its environment variable.
+// viper.IsSet can't be used, because bindEnv sets a default for every key.
+func isConfigured(key string) bool {
+	return viper.InConfig(key) || os.Getenv(envPrefix+"_"+envName(key)) != ""
+}
+
+// loadMangaColors parses the manga colors separately from the rest of the config, so invalid
+// colors only cause a warning instead of failing to unmarshal the whole config.
+func (c *AppConfig) loadMangaColors() {
+	if !viper.IsSet("mangaColors") {
+		return
+	}
+
+	colors := make(map[string]int)
+	for manga, value := range viper.GetStringMap("mangaColors") {
+		color, err := parseColor(value)
+		if err != nil {
+			log.Printf("invalid color for manga %q, using default: %q", manga, err)
+			continue
+		}
+		colors[manga] = color
+	}
+
+	c.Config.MangaColors = colors
+}
+
+// parseColor parses a color given as number or as hex string like "#F4A030" or "0xF4A030".
+func parseColor(value any) (int, error) {
+	var color int64
+	switch v := value.(type) {
+	case int64:
+		color = v
+	case int:
+		color = int64(v)
+	case string:
+		hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(v), "#"), "0x")
+		parsed, err := strconv.ParseInt(hex, 16, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "invalid hex color: %s", v)
+		}
+		color = parsed
+	default:
+		return 0, errors.New("invalid color: %v", v)
 	}
+
+	if color < 0 || color > 0xFFFFFF {
+		return 0, errors.New("color out of range: %#x", color)
+	}
+
+	return int(color), nil
+}
+
+// readConfig reads the config file into viper. If SOPS support is enabled and the file
+// contains SOPS metadata, it gets decrypted before being parsed as TOML.
+func (c *AppConfig) readConfig() error {
+	err := viper.ReadInConfig()
+	if os.Getenv("TCB_BOT__SOPS_ENABLED") != "true" {
+		return err
+	}
+
+	// an encrypted file isn't valid TOML, so only parse errors are worth a second look
+	var parseErr viper.ConfigParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+
+	configFile := viper.ConfigFileUsed()
+	data, readErr := os.ReadFile(configFile)
+	if readErr != nil {
+		return errors.Wrap(readErr, "could not read config file: %s", configFile)
+	}
+
+	if !hasSopsMetadata(data) {
+		return err
+	}
+
+	// sops has no TOML store, encrypted config files are stored in its binary format
+	cleartext, err := decrypt.Data(data, "binary")
+	if err != nil {
+		return errors.Wrap(err, "could not decrypt config file: %s", configFile)
+	}
+	c.encrypted = true
+
+	return viper.ReadConfig(bytes.NewReader(cleartext))
+}
+
+// hasSopsMetadata checks for the top-level sops key that is added to every encrypted file.
+func hasSopsMetadata(data []byte) bool {
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(data, &content); err != nil {
+		return false
+	}
+
+	_, ok := content["sops"]
+	return ok
 }
 
 func (c *AppConfig) DynamicReload(log logger.Logger) {
+	// encrypted config files can't be re-read from disk by viper
+	if c.encrypted {
+		log.Debug().Msg("config file is encrypted, dynamic reload is disabled")
+		return
+	}
+
 	viper.OnConfigChange(func(e fsnotify.Event) {
 		c.m.Lock()
 
 		logLevel := viper.GetString("logLevel")
 		c.Config.LogLevel = logLevel
-		log.SetLogLevel(c.Config.LogLevel)
+		log.SetLogLevel(c.Config.EffectiveLogLevel())
 
 		logPath := viper.GetString("logPath")
 		c.Config.LogPath = logPath
@@ -251,16 +1034,49 @@ func (c *AppConfig) DynamicReload(log logger.Logger) {
 		watchedMangas := viper.GetStringSlice("watchedMangas")
 		c.Config.WatchedMangas = watchedMangas
 
+		mangaSleepTimers := map[string]int{}
+		if err := viper.UnmarshalKey("mangaSleepTimers", &mangaSleepTimers); err != nil {
+			log.Error().Err(err).Msg("error reloading mangaSleepTimers")
+		}
+		c.Config.MangaSleepTimers = mangaSleepTimers
+
 		log.Debug().Msg("config file reloaded!")
 
 		c.m.Unlock()
+
+		for _, hook := range c.reloadHooks {
+			hook()
+		}
 	})
 	viper.WatchConfig()
 
 	return
 }
 
+// RenderNotification renders the description of a chapter notification using the notification template. The
+// release time is rendered in the displayTimezone.
+func (c *AppConfig) RenderNotification(chapter domain.ChapterInfo) (string, error) {
+	chapter.ReleaseTime = chapter.FormattedTime(c.Config.DisplayTimezone)
+
+	var buf bytes.Buffer
+	if err := c.notificationTemplate.Execute(&buf, chapter); err != nil {
+		return "", errors.Wrap(err, "could not render notification template")
+	}
+
+	return buf.String(), nil
+}
+
+// OnReload registers a function that is called after the config file was reloaded.
+func (c *AppConfig) OnReload(hook func()) {
+	c.reloadHooks = append(c.reloadHooks, hook)
+}
+
 func (c *AppConfig) UpdateConfig() error {
+	// never rewrite encrypted config files
+	if c.encrypted {
+		return nil
+	}
+
 	filePath := path.Join(c.Config.ConfigPath, "config.toml")
 
 	f, err := os.ReadFile(filePath)
@@ -272,13 +1088,167 @@ func (c *AppConfig) UpdateConfig() error {
 	lines = c.processLines(lines)
 
 	output := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(output), 0644); err != nil {
+	if err := writeFileAtomic(filePath, []byte(output)); err != nil {
 		return errors.Wrap(err, "could not write config file: %s", filePath)
 	}
 
 	return nil
 }
 
+// UpdateWatchedMangas replaces the watched mangas with the result of fn and writes them to the config file. fn gets
+// a copy of the current watched mangas and runs while the config is locked, so concurrent updates can't get lost.
+func (c *AppConfig) UpdateWatchedMangas(fn func(watchedMangas []string) []string) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	watchedMangas := fn(slices.Clone(c.Config.WatchedMangas))
+	c.Config.WatchedMangas = watchedMangas
+
+	// encrypted config files are only updated in memory
+	if c.encrypted {
+		return nil
+	}
+
+	return c.rewriteConfig(func(lines []string) []string {
+		return setLine(lines, "watchedMangas", tomlArray(watchedMangas))
+	})
+}
+
+// UpdateWatchlist replaces the watched mangas and manga channels with the result of fn and writes them to the
+// config file, like UpdateWatchedMangas.
+func (c *AppConfig) UpdateWatchlist(fn func(watchlist domain.Watchlist) domain.Watchlist) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	watchlist := fn(domain.Watchlist{
+		WatchedMangas: slices.Clone(c.Config.WatchedMangas),
+		MangaChannels: maps.Clone(c.Config.MangaChannels),
+	})
+	c.Config.WatchedMangas = watchlist.WatchedMangas
+	c.Config.MangaChannels = watchlist.MangaChannels
+
+	// encrypted config files are only updated in memory
+	if c.encrypted {
+		return nil
+	}
+
+	return c.rewriteConfig(func(lines []string) []string {
+		lines = setLine(lines, "watchedMangas", tomlArray(watchlist.WatchedMangas))
+		return setLine(lines, "mangaChannels", tomlInlineTable(watchlist.MangaChannels))
+	})
+}
+
+// SetMangaCover sets the cover of the manga and writes all manga covers to the config file.
+func (c *AppConfig) SetMangaCover(mangaTitle string, coverURL string) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.Config.MangaCovers == nil {
+		c.Config.MangaCovers = make(map[string]string)
+	}
+	c.Config.MangaCovers[mangaTitle] = coverURL
+
+	// encrypted config files are only updated in memory
+	if c.encrypted {
+		return nil
+	}
+
+	mangaCovers := c.Config.MangaCovers
+	return c.rewriteConfig(func(lines []string) []string {
+		return setLine(lines, "mangaCovers", tomlInlineTable(mangaCovers))
+	})
+}
+
+// rewriteConfig applies update to the lines of the config file.
+func (c *AppConfig) rewriteConfig(update func(lines []string) []string) error {
+	filePath := path.Join(c.Config.ConfigPath, "config.toml")
+
+	f, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.Wrap(err, "could not read config filePath: %s", filePath)
+	}
+
+	lines := strings.Split(string(f), "\n")
+	lines = update(lines)
+
+	output := strings.Join(lines, "\n")
+	if err := writeFileAtomic(filePath, []byte(output)); err != nil {
+		return errors.Wrap(err, "could not write config file: %s", filePath)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to filePath and renames it afterwards, so the config file
+// is never left half-written, e.g. if viper reads it while it's being written.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with 0600, the config file keeps the permissions it always had
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// setLine replaces the line of the key, even if it's commented out, or adds it in front of the first
+// table if there is none, so it doesn't end up inside the table.
+func setLine(lines []string, key string, value string) []string {
+	line := fmt.Sprintf("%s = %s", key, value)
+
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimLeft(l, "# "), key+" =") {
+			lines[i] = line
+			return lines
+		}
+	}
+
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "[") {
+			return slices.Insert(lines, i, line, "")
+		}
+	}
+
+	return append(lines, line)
+}
+
+func tomlArray(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+
+	return fmt.Sprintf("[ %s ]", strings.Join(quoted, ", "))
+}
+
+func tomlInlineTable(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s = %s", strconv.Quote(key), strconv.Quote(values[key])))
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(pairs, ", "))
+}
+
 func (c *AppConfig) processLines(lines []string) []string {
 	// keep track of not found values to append at bottom
 	var (
@@ -0,0 +1,417 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"tcb-bot/internal/domain"
+
+	"github.com/spf13/viper"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "sleepTimer", want: "SLEEP_TIMER"},
+		{key: "discordChannelID", want: "DISCORD_CHANNEL_ID"},
+		{key: "discordToken", want: "DISCORD_TOKEN"},
+		{key: "collectedChaptersDB", want: "COLLECTED_CHAPTERS_DB"},
+		{key: "notifyOnStartup", want: "NOTIFY_ON_STARTUP"},
+		{key: "otelEndpoint", want: "OTEL_ENDPOINT"},
+		{key: "apiPort", want: "API_PORT"},
+		{key: "host", want: "HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := envName(tt.key); got != tt.want {
+				t.Errorf("envName(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewFromEnv sets every field of the config that can be set using an environment variable and checks that all of
+// them end up in the config. Fields that are validated get a valid value, all others a value derived from their name.
+func TestNewFromEnv(t *testing.T) {
+	// viper is global, every config must start from scratch
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	valid := map[string]string{
+		"DISCORD_CHANNEL_ID":            "100000000000000001",
+		"DISCORD_HIATUS_CHANNEL_ID":     "100000000000000002",
+		"DISCORD_FORUM_CHANNEL_ID":      "100000000000000003",
+		"DISCORD_ERROR_CHANNEL_ID":      "100000000000000004",
+		"DISCORD_WARN_CHANNEL_ID":       "100000000000000005",
+		"DISCORD_CRITICAL_CHANNEL_ID":   "100000000000000006",
+		"DISCORD_FOOTER_ICON_URL":       "https://example.com/footer.png",
+		"DISCORD_EMBED_AUTHOR_URL":      "https://example.com/author",
+		"DISCORD_EMBED_AUTHOR_ICON_URL": "https://example.com/author.png",
+		"DISCORD_REACT_EMOJI":           "tcb:300000000000000000",
+		"NOTIFIER":                      "both",
+		"NOTIFICATION_STYLE":            domain.NotificationStylePlain,
+		"COLLECTED_CHAPTERS_DB":         filepath.Join(dir, "collected_chapters.db"),
+		"LOG_LEVEL":                     "TRACE",
+		"LOG_FORMAT":                    domain.LogFormatConsole,
+		"SCRAPE_URLS":                   "https://example.com,https://mirror.example.com",
+		"SCRAPE_PROXY_URL":              "socks5://proxy.example.com:1080",
+		"SCRAPE_MODE":                   "both",
+		"RSS_FEED_URL":                  "https://example.com/rss",
+		"SENTRY_DSN":                    "https://public@sentry.example.com/1",
+		"OTEL_ENDPOINT":                 "https://otel.example.com",
+		"DISPLAY_TIMEZONE":              "Asia/Tokyo",
+		"QUIET_HOURS_START":             "23:00",
+		"QUIET_HOURS_END":               "07:00",
+		"QUIET_HOURS_TZ":                "America/New_York",
+		"NOTIFICATION_TEMPLATE":         "{{.MangaTitle}} {{.ChapterNumber}}",
+		"SMTP_PORT":                     "2525",
+		"SMTP_FROM":                     "bot@example.com",
+		"SMTP_TO":                       "a@example.com,b@example.com",
+	}
+
+	fields := envFields(reflect.TypeOf(domain.Config{}), "")
+	want := reflect.New(reflect.TypeOf(domain.Config{})).Elem()
+	for i, field := range fields {
+		value, ok := valid[field.name]
+		if !ok {
+			value = envValue(t, field, i)
+		}
+		t.Setenv(envPrefix+"_"+field.name, value)
+		want.FieldByIndex(field.index).Set(parseEnvValue(t, field, value))
+	}
+
+	cfg := New(dir, "test")
+	got := reflect.ValueOf(cfg.Config).Elem()
+	for _, field := range fields {
+		got, want := got.FieldByIndex(field.index).Interface(), want.FieldByIndex(field.index).Interface()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", field.name, got, want)
+		}
+	}
+}
+
+// envValue returns a value for the field that differs from the values of all other fields, so a variable that is bound
+// to the wrong field is noticed.
+func envValue(t *testing.T, field envField, i int) string {
+	t.Helper()
+
+	switch field.typ.Kind() {
+	case reflect.String:
+		return strings.ToLower(field.name)
+	case reflect.Int:
+		return strconv.Itoa(100 + i)
+	case reflect.Bool:
+		return "true"
+	case reflect.Slice:
+		if field.typ.Elem().Kind() == reflect.Int {
+			return fmt.Sprintf("%d,%d", 100+i, 1000+i)
+		}
+		return strings.ToLower(field.name) + "_1," + strings.ToLower(field.name) + "_2"
+	default:
+		t.Fatalf("%s: no value for fields of type %s", field.name, field.typ)
+		return ""
+	}
+}
+
+// parseEnvValue returns the value the field should have after setting its environment variable to value.
+func parseEnvValue(t *testing.T, field envField, value string) reflect.Value {
+	t.Helper()
+
+	parseInt := func(s string) int {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			t.Fatalf("%s: %v", field.name, err)
+		}
+		return n
+	}
+
+	switch field.typ.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(value)
+	case reflect.Int:
+		return reflect.ValueOf(parseInt(value))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			t.Fatalf("%s: %v", field.name, err)
+		}
+		return reflect.ValueOf(b)
+	case reflect.Slice:
+		items := strings.Split(value, ",")
+		if field.typ.Elem().Kind() == reflect.Int {
+			var ints []int
+			for _, item := range items {
+				ints = append(ints, parseInt(item))
+			}
+			return reflect.ValueOf(ints)
+		}
+		return reflect.ValueOf(items)
+	default:
+		t.Fatalf("%s: can't parse fields of type %s", field.name, field.typ)
+		return reflect.Value{}
+	}
+}
+
+// TestEnvDocumented makes sure every field of the config that can be set using an environment variable is listed in
+// docker-compose.yml. Maps and tables like guilds can only be set in config.toml.
+func TestEnvDocumented(t *testing.T) {
+	compose, err := os.ReadFile("../../docker-compose.yml")
+	if err != nil {
+		t.Fatalf("could not read docker-compose.yml: %v", err)
+	}
+
+	for _, name := range envNames(reflect.TypeOf(domain.Config{}), "") {
+		if !strings.Contains(string(compose), "- "+envPrefix+"_"+name+"=") {
+			t.Errorf("%s%s is missing in docker-compose.yml", envPrefix+"_", name)
+		}
+	}
+}
+
+// envField is a field of the config that can be set using an environment variable.
+type envField struct {
+	name  string // without the prefix
+	index []int
+	typ   reflect.Type
+}
+
+// envNames returns the environment variable names of the fields of typ without the prefix, like bindFields.
+func envNames(typ reflect.Type, namePrefix string) []string {
+	var names []string
+	for _, field := range envFields(typ, namePrefix) {
+		names = append(names, field.name)
+	}
+
+	return names
+}
+
+// envFields returns the fields of typ that can be set using an environment variable, like bindFields. Maps and tables
+// like guilds are skipped.
+func envFields(typ reflect.Type, namePrefix string) []envField {
+	var fields []envField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Tag.Get("toml") == "" || field.Tag.Get("mapstructure") == "-" {
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			name = envName(field.Tag.Get("toml"))
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			for _, nested := range envFields(field.Type, namePrefix+name+"_") {
+				nested.index = append([]int{i}, nested.index...)
+				fields = append(fields, nested)
+			}
+		case field.Type.Kind() == reflect.Map:
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+		default:
+			fields = append(fields, envField{name: namePrefix + name, index: []int{i}, typ: field.Type})
+		}
+	}
+
+	return fields
+}
+
+func TestRenderNotification(t *testing.T) {
+	chapter := domain.ChapterInfo{
+		ReleaseLink:   "/chapters/7700/one-piece-chapter-1100",
+		MangaTitle:    "One Piece",
+		ChapterNumber: "1100",
+		ChapterTitle:  "The Bonney Pirates",
+		ReleaseTime:   "2024-01-05T12:00:00Z",
+	}
+	untitled := chapter
+	untitled.ChapterTitle = ""
+
+	tests := []struct {
+		name     string
+		template string
+		timezone string
+		chapter  domain.ChapterInfo
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:    "default template",
+			chapter: chapter,
+			want:    "Chapter 1100: The Bonney Pirates\n",
+		},
+		{
+			name:    "default template without chapter title",
+			chapter: untitled,
+			want:    "Chapter 1100\n",
+		},
+		{
+			name:     "all variables",
+			template: "{{.MangaTitle}} {{.ChapterNumber}} {{.ChapterTitle}} {{.ReleaseTime}} {{.ReleaseLink}}",
+			chapter:  chapter,
+			want:     "One Piece 1100 The Bonney Pirates Fri, 05 Jan 2024 12:00:00 +0000 /chapters/7700/one-piece-chapter-1100",
+		},
+		{
+			name:     "empty chapter title",
+			template: "{{.MangaTitle}} {{.ChapterNumber}}: {{.ChapterTitle}}",
+			chapter:  untitled,
+			want:     "One Piece 1100: ",
+		},
+		{
+			name:     "release time in display timezone",
+			template: "{{.ReleaseTime}}",
+			timezone: "Europe/Berlin",
+			chapter:  chapter,
+			want:     "Fri, 05 Jan 2024 13:00:00 +0100",
+		},
+		{
+			name:     "conditional chapter title",
+			template: "{{if .ChapterTitle}}{{.ChapterTitle}}{{else}}Untitled{{end}}",
+			chapter:  untitled,
+			want:     "Untitled",
+		},
+		{
+			name:     "template functions",
+			template: `{{printf "%s #%s" .MangaTitle .ChapterNumber}}`,
+			chapter:  chapter,
+			want:     "One Piece #1100",
+		},
+		{
+			name:     "unknown variable",
+			template: "{{.Volume}}",
+			chapter:  chapter,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewDefault(func(cfg *domain.Config) {
+				cfg.DiscordToken = "test-token"
+				cfg.DiscordChannelID = "100000000000000000"
+				cfg.CollectedChaptersDB = ":memory:"
+				if tt.template != "" {
+					cfg.NotificationTemplate = tt.template
+				}
+				if tt.timezone != "" {
+					cfg.DisplayTimezone = tt.timezone
+				}
+			})
+			if err != nil {
+				t.Fatalf("could not create config: %v", err)
+			}
+
+			got, err := cfg.RenderNotification(tt.chapter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("RenderNotification() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderNotification() returned an error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderNotification() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDefaultInvalidTemplate(t *testing.T) {
+	_, err := NewDefault(func(cfg *domain.Config) {
+		cfg.DiscordToken = "test-token"
+		cfg.DiscordChannelID = "100000000000000000"
+		cfg.CollectedChaptersDB = ":memory:"
+		cfg.NotificationTemplate = "Chapter {{.ChapterNumber"
+	})
+	if err == nil {
+		t.Fatal("NewDefault() accepted an invalid notification template")
+	}
+}
+
+// TestUpdateWatchedMangasConcurrent updates the watched mangas from several goroutines at once, like the slash
+// commands do. Run it using -race.
+func TestUpdateWatchedMangasConcurrent(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	t.Setenv("TCB_BOT__DISCORD_TOKEN", "test-token")
+	t.Setenv("TCB_BOT__DISCORD_CHANNEL_ID", "100000000000000000")
+	t.Setenv("TCB_BOT__COLLECTED_CHAPTERS_DB", dir+"/collected_chapters.db")
+	cfg := New(dir, "test")
+	cfg.Config.WatchedMangas = nil
+
+	const updates = 20
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	errs := make(chan error, updates)
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mangaTitle := fmt.Sprintf("Manga %d", i)
+
+			// both methods must lock the config, so updates of one can't overwrite those of the other
+			var err error
+			if i%2 == 0 {
+				err = cfg.UpdateWatchedMangas(func(watchedMangas []string) []string {
+					calls.Add(1)
+					return append(watchedMangas, mangaTitle)
+				})
+			} else {
+				err = cfg.UpdateWatchlist(func(watchlist domain.Watchlist) domain.Watchlist {
+					calls.Add(1)
+					watchlist.WatchedMangas = append(watchlist.WatchedMangas, mangaTitle)
+					return watchlist
+				})
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("could not update watched mangas: %v", err)
+		}
+	}
+	if got := calls.Load(); got != updates {
+		t.Errorf("update functions were called %d times, want %d", got, updates)
+	}
+
+	file, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	if err != nil {
+		t.Fatalf("could not read config file: %v", err)
+	}
+	if got := len(cfg.Config.WatchedMangas); got != updates {
+		t.Errorf("got %d watched mangas, want %d: %q", got, updates, cfg.Config.WatchedMangas)
+	}
+	for i := 0; i < updates; i++ {
+		mangaTitle := fmt.Sprintf("Manga %d", i)
+		if !slices.Contains(cfg.Config.WatchedMangas, mangaTitle) {
+			t.Errorf("%q was lost", mangaTitle)
+		}
+		if !strings.Contains(string(file), fmt.Sprintf("%q", mangaTitle)) {
+			t.Errorf("%q is missing in the config file", mangaTitle)
+		}
+	}
+
+	// temporary files of the atomic writes must not be left behind
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("temporary files were left behind: %q", tmp)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tcb-bot/internal/domain"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+)
+
+// legacyKeys maps keys of the legacy YAML config to their current name.
+var legacyKeys = map[string]string{
+	"collectedChaptersFilePath": "collectedChaptersDB",
+}
+
+// MigrateYAML converts the legacy YAML config at from into a TOML config at to, based on the config template.
+// It returns the keys of the YAML config that don't exist anymore and were skipped.
+func MigrateYAML(from string, to string) ([]string, error) {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read legacy config: %s", from)
+	}
+
+	var legacy map[string]any
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return nil, errors.Wrap(err, "could not parse legacy config: %s", from)
+	}
+
+	tomlKeys := configKeys()
+	values := make(map[string]any)
+	var skipped []string
+	for key, value := range legacy {
+		if renamed, ok := legacyKeys[key]; ok {
+			key = renamed
+		}
+
+		i := slices.IndexFunc(tomlKeys, func(tomlKey string) bool { return strings.EqualFold(tomlKey, key) })
+		if i < 0 {
+			skipped = append(skipped, key)
+			continue
+		}
+		values[tomlKeys[i]] = value
+	}
+	slices.Sort(skipped)
+
+	cfg := &domain.Config{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "toml",
+		WeaklyTypedInput: true,
+		Result:           cfg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(values); err != nil {
+		return nil, errors.Wrap(err, "could not convert legacy config: %s", from)
+	}
+
+	lines := strings.Split(configTemplate, "\n")
+	value := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		key := strings.Split(value.Type().Field(i).Tag.Get("toml"), ",")[0]
+		if _, ok := values[key]; !ok {
+			continue
+		}
+
+		encoded, err := tomlValue(value.Field(i))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not convert %s", key)
+		}
+		// the template spells every key in camel case, even if the toml tag doesn't
+		lines = setLine(lines, strings.ToLower(key[:1])+key[1:], encoded)
+	}
+
+	if _, ok := values["discordErrorChannelID"]; !ok {
+		lines = setLine(lines, "discordErrorChannelID", `"" # TODO: didn't exist in the legacy config, errors are sent to discordChannelID until it's set`)
+	}
+
+	if err := os.WriteFile(to, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return nil, errors.Wrap(err, "could not write config file: %s", to)
+	}
+
+	return skipped, nil
+}
+
+// configKeys returns the toml keys of all config fields.
+func configKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(domain.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]; key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// tomlValue encodes the value as inline TOML, tables are written as inline tables.
+func tomlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := tomlValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+		}
+		return fmt.Sprintf("[ %s ]", strings.Join(values, ", ")), nil
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		slices.Sort(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value, err := tomlValue(v.MapIndex(reflect.ValueOf(key)))
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, fmt.Sprintf("%s = %s", strconv.Quote(key), value))
+		}
+		return fmt.Sprintf("{ %s }", strings.Join(pairs, ", ")), nil
+	case reflect.Struct:
+		var pairs []string
+		for i := 0; i < v.NumField(); i++ {
+			key := strings.Split(v.Type().Field(i).Tag.Get("toml"), ",")[0]
+			if key == "" || v.Field(i).IsZero() {
+				continue
+			}
+
+			value, err := tomlValue(v.Field(i))
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, fmt.Sprintf("%s = %s", key, value))
+		}
+		return fmt.Sprintf("{ %s }", strings.Join(pairs, ", ")), nil
+	default:
+		return "", errors.New("unsupported type: %s", v.Type())
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"text/template"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+var (
+	// restartKeys can't be changed without restarting the bot
+	restartKeys = []string{"discordToken", "discordWebhookURL", "collectedChaptersDB", "dbMaxOpenConns",
+		"dbMaxIdleConns", "logPath", "logFormat", "logMaxSize", "logMaxBackups", "logRotateDaily", "healthCheckPort",
+		"scrapeProxyURL", "userAgents", "scrapeHeaders", "notifier", "telegramBotToken", "scrapeTimeoutSeconds",
+		"scrapeMaxBodyBytes", "discordRateLimit", "sentryDSN", "otelEndpoint", "otelServiceName",
+		"shutdownTimeoutSeconds", "cacheEnabled", "scrapeCachePath"}
+
+	// secretKeys are never logged
+	secretKeys = []string{"discordToken", "discordWebhookURL", "apiToken", "telegramBotToken", "sentryDSN", "smtp"}
+)
+
+// Reload re-reads the config file and replaces all fields of the config. Fields that need a restart keep
+// their old value. It returns the keys of all fields that were changed.
+func (c *AppConfig) Reload(log logger.Logger) ([]string, error) {
+	if err := c.readConfig(); err != nil {
+		return nil, errors.Wrap(err, "could not read config file")
+	}
+
+	fresh := &AppConfig{}
+	fresh.defaults()
+	if err := unmarshal(fresh.Config); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal config file")
+	}
+	fresh.loadMangaColors()
+
+	if err := ValidateConfig(fresh.Config); err != nil {
+		return nil, fmt.Errorf("%w:\n%s", domain.ErrConfigInvalid, formatValidationError(err))
+	}
+
+	tmpl, err := template.New("notification").Parse(fresh.Config.NotificationTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "notificationTemplate must be a valid template")
+	}
+
+	c.m.Lock()
+
+	fresh.Config.Version = c.Config.Version
+	fresh.Config.ConfigPath = c.Config.ConfigPath
+	fresh.Config.DryRun = c.Config.DryRun
+	fresh.Config.Verbose = c.Config.Verbose
+
+	var changed []string
+	oldValue, newValue := reflect.ValueOf(c.Config).Elem(), reflect.ValueOf(fresh.Config).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		key := oldValue.Type().Field(i).Tag.Get("toml")
+		if key == "" || reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+
+		if slices.Contains(restartKeys, key) {
+			log.Warn().Msgf("config %s changed, restart tcb-bot to apply it", key)
+			newValue.Field(i).Set(oldValue.Field(i))
+			continue
+		}
+
+		if slices.Contains(secretKeys, key) {
+			log.Info().Msgf("config %s changed", key)
+		} else {
+			log.Info().Msgf("config %s changed: %v → %v", key, formatValue(oldValue.Field(i)),
+				formatValue(newValue.Field(i)))
+		}
+		changed = append(changed, key)
+	}
+
+	// update in place, other modules keep a pointer to the config
+	*c.Config = *fresh.Config
+	c.notificationTemplate = tmpl
+
+	c.m.Unlock()
+
+	log.SetLogLevel(c.Config.EffectiveLogLevel())
+	log.Info().Msgf("config file reloaded, %d fields changed", len(changed))
+
+	for _, hook := range c.reloadHooks {
+		hook()
+	}
+
+	return changed, nil
+}
+
+func formatValue(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return fmt.Sprintf("%q", value.String())
+	}
+
+	return fmt.Sprintf("%v", value.Interface())
+}
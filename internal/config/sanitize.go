@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"tcb-bot/internal/domain"
+)
+
+// redacted replaces the values of secrets in sanitized configs
+const redacted = "REDACTED"
+
+// Sanitize returns a copy of the config with the values of all secretKeys redacted, so it can be logged or shown.
+func (c *AppConfig) Sanitize() domain.Config {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	sanitized := *c.Config
+	value := reflect.ValueOf(&sanitized).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		key := strings.Split(value.Type().Field(i).Tag.Get("toml"), ",")[0]
+		if slices.Contains(secretKeys, key) {
+			redact(value.Field(i))
+		}
+	}
+
+	return sanitized
+}
+
+// redact replaces all strings in value that aren't empty, including those in tables and lists.
+func redact(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.String:
+		if value.String() != "" {
+			value.SetString(redacted)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				redact(value.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if value.IsNil() {
+			return
+		}
+
+		// the slice is shared with the config, it must not be changed in place
+		redactedSlice := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(redactedSlice, value)
+		for i := 0; i < redactedSlice.Len(); i++ {
+			redact(redactedSlice.Index(i))
+		}
+		value.Set(redactedSlice)
+	}
+}
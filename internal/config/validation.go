@@ -0,0 +1,372 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+
+	"github.com/getsentry/sentry-go"
+	"golang.org/x/net/http/httpguts"
+)
+
+var (
+	logLevels   = []string{"ERROR", "DEBUG", "INFO", "WARN", "TRACE"}
+	logFormats  = []string{"json", domain.LogFormatConsole}
+	notifiers   = []string{"discord", "telegram", "both", "smtp"}
+	styles      = []string{domain.NotificationStyleEmbed, domain.NotificationStylePlain}
+	scrapeModes = []string{"html", "rss", "both"}
+)
+
+// ValidateConfig checks every field of the config and returns all problems it found joined into
+// a single error.
+func ValidateConfig(cfg *domain.Config) error {
+	return validateConfig(cfg, true)
+}
+
+// ValidateScrapeConfig checks the config like ValidateConfig, but doesn't require a notifier to be configured.
+func ValidateScrapeConfig(cfg *domain.Config) error {
+	return validateConfig(cfg, false)
+}
+
+func validateConfig(cfg *domain.Config, requireNotifier bool) error {
+	var errs []error
+
+	// the directory of the database is created when it's opened
+	if cfg.CollectedChaptersDB == "" {
+		errs = append(errs, errors.New("collectedChaptersDB must be provided"))
+	}
+
+	if !slices.Contains(notifiers, cfg.Notifier) {
+		errs = append(errs, fmt.Errorf("notifier: must be one of %s, got %q", strings.Join(notifiers, ", "),
+			cfg.Notifier))
+	}
+
+	if !slices.Contains(styles, cfg.NotificationStyle) {
+		errs = append(errs, fmt.Errorf("notificationStyle: must be one of %s, got %q", strings.Join(styles, ", "),
+			cfg.NotificationStyle))
+	}
+
+	if requireNotifier {
+		errs = append(errs, validateNotifier(cfg)...)
+	}
+
+	if cfg.DiscordRateLimit < 1 {
+		errs = append(errs, fmt.Errorf("discordRateLimit: must be at least 1, got %d", cfg.DiscordRateLimit))
+	}
+
+	for _, channel := range [][2]string{
+		{"discordChannelID", cfg.DiscordChannelID},
+		{"discordHiatusChannelID", cfg.DiscordHiatusChannelID},
+		{"discordForumChannelID", cfg.DiscordForumChannelID},
+		{"discordErrorChannelID", cfg.DiscordErrorChannelID},
+		{"discordWarnChannelID", cfg.DiscordWarnChannelID},
+		{"discordCriticalChannelID", cfg.DiscordCriticalChannelID},
+	} {
+		if channel[1] != "" && !isDigits(channel[1]) {
+			errs = append(errs, fmt.Errorf("%s: must only contain digits, got %q", channel[0], channel[1]))
+		}
+	}
+
+	for _, mangaTitle := range sortedKeys(cfg.MangaRoles) {
+		roleID := cfg.MangaRoles[mangaTitle]
+		if roleID == "" || !isDigits(roleID) {
+			errs = append(errs, fmt.Errorf("mangaRoles: role of %q must only contain digits, got %q", mangaTitle, roleID))
+		} else if slices.ContainsFunc(cfg.Guilds, func(guild domain.GuildConfig) bool { return guild.GuildID == roleID }) {
+			errs = append(errs, fmt.Errorf("mangaRoles: role of %q is the @everyone role", mangaTitle))
+		}
+	}
+
+	for _, message := range []struct {
+		key   string
+		texts map[string]string
+	}{
+		{"mangaMessagePrefix", cfg.MangaMessagePrefix},
+		{"mangaMessageSuffix", cfg.MangaMessageSuffix},
+	} {
+		for _, mangaTitle := range sortedKeys(message.texts) {
+			if text := message.texts[mangaTitle]; strings.Contains(text, "@everyone") || strings.Contains(text, "@here") {
+				errs = append(errs, fmt.Errorf("%s: text of %q must not mention @everyone or @here, use <@&role ID> "+
+					"to ping a role", message.key, mangaTitle))
+			}
+		}
+	}
+
+	if cfg.MangaPingCooldownMinutes < 0 {
+		errs = append(errs, fmt.Errorf("mangaPingCooldownMinutes: must not be negative, got %d",
+			cfg.MangaPingCooldownMinutes))
+	}
+
+	if cfg.MangaErrorCooldownMinutes < 0 {
+		errs = append(errs, fmt.Errorf("mangaErrorCooldownMinutes: must not be negative, got %d",
+			cfg.MangaErrorCooldownMinutes))
+	}
+
+	if cfg.MaxNotificationsPerRun < 0 {
+		errs = append(errs, fmt.Errorf("maxNotificationsPerRun: must not be negative, got %d",
+			cfg.MaxNotificationsPerRun))
+	}
+
+	for _, bounds := range []struct {
+		key      string
+		chapters map[string]string
+	}{
+		{"mangaStartChapter", cfg.MangaStartChapter},
+		{"mangaEndChapter", cfg.MangaEndChapter},
+	} {
+		for _, mangaTitle := range sortedKeys(bounds.chapters) {
+			if _, err := strconv.ParseFloat(bounds.chapters[mangaTitle], 64); err != nil {
+				errs = append(errs, fmt.Errorf("%s: chapter of %q must be a number, got %q", bounds.key, mangaTitle,
+					bounds.chapters[mangaTitle]))
+			}
+		}
+	}
+
+	for _, mangaTitle := range sortedKeys(cfg.MangaStartChapter) {
+		start, err := strconv.ParseFloat(cfg.MangaStartChapter[mangaTitle], 64)
+		if err != nil {
+			continue
+		}
+		if end, ok := utils.LookupTitle(cfg.MangaEndChapter, mangaTitle); ok {
+			if end, err := strconv.ParseFloat(end, 64); err == nil && end < start {
+				errs = append(errs, fmt.Errorf("mangaStartChapter & mangaEndChapter: start chapter of %q is after its "+
+					"end chapter", mangaTitle))
+			}
+		}
+	}
+
+	aliasOf := make(map[string]string)
+	for _, mangaTitle := range sortedKeys(cfg.MangaAliases) {
+		for _, alias := range cfg.MangaAliases[mangaTitle] {
+			alias = strings.ToLower(strings.TrimSpace(alias))
+			if alias == "" {
+				errs = append(errs, fmt.Errorf("mangaAliases: aliases of %q must not be empty", mangaTitle))
+			} else if other, ok := aliasOf[alias]; ok && other != mangaTitle {
+				errs = append(errs, fmt.Errorf("mangaAliases: alias %q is used by both %q and %q", alias, other,
+					mangaTitle))
+			} else {
+				aliasOf[alias] = mangaTitle
+			}
+		}
+	}
+
+	for i, guild := range cfg.Guilds {
+		if guild.DiscordChannelID == "" {
+			errs = append(errs, fmt.Errorf("guilds[%d].discordChannelID must be provided", i))
+		} else if !isDigits(guild.DiscordChannelID) {
+			errs = append(errs, fmt.Errorf("guilds[%d].discordChannelID: must only contain digits, got %q", i,
+				guild.DiscordChannelID))
+		}
+	}
+
+	if !slices.Contains(logLevels, cfg.LogLevel) {
+		errs = append(errs, fmt.Errorf("logLevel: must be one of %s, got %q", strings.Join(logLevels, ", "),
+			cfg.LogLevel))
+	}
+
+	if !slices.Contains(logFormats, cfg.LogFormat) {
+		errs = append(errs, fmt.Errorf("logFormat: must be one of %s, got %q", strings.Join(logFormats, ", "),
+			cfg.LogFormat))
+	}
+
+	if cfg.LogMaxSize < 1 {
+		errs = append(errs, fmt.Errorf("logMaxSize: must be at least 1, got %d", cfg.LogMaxSize))
+	}
+
+	if cfg.SleepTimer < 1 {
+		errs = append(errs, fmt.Errorf("sleepTimer: must be at least 1, got %d", cfg.SleepTimer))
+	}
+
+	if slices.ContainsFunc(cfg.WatchedMangas, func(manga string) bool { return strings.TrimSpace(manga) == "" }) {
+		errs = append(errs, errors.New("watchedMangas: must not contain empty titles"))
+	}
+
+	if cfg.ShutdownTimeoutSeconds < 1 {
+		errs = append(errs, fmt.Errorf("shutdownTimeoutSeconds: must be at least 1, got %d", cfg.ShutdownTimeoutSeconds))
+	}
+
+	if cfg.ScrapeTimeoutSeconds < 1 {
+		errs = append(errs, fmt.Errorf("scrapeTimeoutSeconds: must be at least 1, got %d", cfg.ScrapeTimeoutSeconds))
+	}
+
+	if cfg.ScrapeMaxBodyBytes < 1 {
+		errs = append(errs, fmt.Errorf("scrapeMaxBodyBytes: must be at least 1, got %d", cfg.ScrapeMaxBodyBytes))
+	}
+
+	if cfg.CacheEnabled && cfg.ScrapeCachePath == "" {
+		errs = append(errs, errors.New("scrapeCachePath: is required if cacheEnabled is true"))
+	}
+
+	if cfg.SentryDSN != "" {
+		if _, err := sentry.NewDsn(cfg.SentryDSN); err != nil {
+			errs = append(errs, fmt.Errorf("sentryDSN: %w", err))
+		}
+	}
+
+	if cfg.DiscordFooterIconURL != "" {
+		if u, err := url.Parse(cfg.DiscordFooterIconURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			errs = append(errs, fmt.Errorf("discordFooterIconURL: must be a http or https url, got %q",
+				cfg.DiscordFooterIconURL))
+		}
+	}
+
+	for _, author := range [][2]string{
+		{"discordEmbedAuthorURL", cfg.DiscordEmbedAuthorURL},
+		{"discordEmbedAuthorIconURL", cfg.DiscordEmbedAuthorIconURL},
+	} {
+		if author[1] == "" {
+			continue
+		}
+		if cfg.DiscordEmbedAuthorName == "" {
+			errs = append(errs, fmt.Errorf("%s: requires discordEmbedAuthorName to be set", author[0]))
+		} else if u, err := url.Parse(author[1]); err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: must be a http or https url, got %q", author[0], author[1]))
+		}
+	}
+
+	if cfg.DiscordReactEmojiEnabled {
+		if cfg.DiscordReactEmoji == "" {
+			errs = append(errs, errors.New("discordReactEmoji: is required if discordReactEmojiEnabled is true"))
+		} else if name, id, custom := strings.Cut(cfg.DiscordReactEmoji, ":"); custom &&
+			(name == "" || id == "" || !isDigits(id)) {
+			errs = append(errs, fmt.Errorf("discordReactEmoji: custom emojis must use the name:id format, got %q",
+				cfg.DiscordReactEmoji))
+		}
+	}
+
+	if !slices.Contains(scrapeModes, cfg.ScrapeMode) {
+		errs = append(errs, fmt.Errorf("scrapeMode: must be one of %s, got %q", strings.Join(scrapeModes, ", "),
+			cfg.ScrapeMode))
+	} else if cfg.ScrapeMode != "html" {
+		if u, err := url.Parse(cfg.RSSFeedURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("rssFeedURL: must be a http or https url if scrapeMode is %q, got %q",
+				cfg.ScrapeMode, cfg.RSSFeedURL))
+		}
+	}
+
+	if len(cfg.ScrapeURLs) == 0 {
+		errs = append(errs, errors.New("scrapeURLs must be provided"))
+	}
+	for _, scrapeURL := range cfg.ScrapeURLs {
+		if u, err := url.Parse(scrapeURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("scrapeURLs: must only contain http or https urls, got %q", scrapeURL))
+		}
+	}
+
+	if cfg.OtelEndpoint != "" {
+		if u, err := url.Parse(cfg.OtelEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("otelEndpoint: must be a http or https url, got %q", cfg.OtelEndpoint))
+		}
+	}
+	if strings.TrimSpace(cfg.OtelServiceName) == "" {
+		errs = append(errs, errors.New("otelServiceName must be provided"))
+	}
+
+	for _, name := range sortedKeys(cfg.ScrapeHeaders) {
+		if !httpguts.ValidHeaderFieldName(name) {
+			errs = append(errs, fmt.Errorf("scrapeHeaders: %q is not a valid header name", name))
+		} else if !httpguts.ValidHeaderFieldValue(cfg.ScrapeHeaders[name]) {
+			errs = append(errs, fmt.Errorf("scrapeHeaders: value of %q is not a valid header value", name))
+		}
+	}
+
+	if cfg.ScrapeProxyURL != "" {
+		if _, err := utils.ParseProxyURL(cfg.ScrapeProxyURL); err != nil {
+			errs = append(errs, fmt.Errorf("scrapeProxyURL: %w", err))
+		}
+	}
+
+	if _, err := time.LoadLocation(cfg.DisplayTimezone); err != nil {
+		errs = append(errs, fmt.Errorf("displayTimezone: %w", err))
+	}
+
+	if _, _, err := utils.QuietHoursEnd(time.Now(), cfg.QuietHoursStart, cfg.QuietHoursEnd, cfg.QuietHoursTZ); err != nil {
+		errs = append(errs, fmt.Errorf("quietHoursStart, quietHoursEnd & quietHoursTZ: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateNotifier checks that the credentials of the configured notifier are provided.
+func validateNotifier(cfg *domain.Config) []error {
+	var errs []error
+
+	if cfg.Notifier != "telegram" && cfg.Notifier != "smtp" && cfg.DiscordWebhookURL == "" &&
+		(cfg.DiscordToken == "" || (cfg.DiscordChannelID == "" && len(cfg.Guilds) == 0)) {
+		errs = append(errs, errors.New("discordToken & discordChannelID or discordWebhookURL must be provided"))
+	}
+
+	if cfg.Notifier == "telegram" || cfg.Notifier == "both" {
+		if cfg.TelegramBotToken == "" || cfg.TelegramChatID == "" {
+			errs = append(errs, errors.New("telegramBotToken & telegramChatID must be provided"))
+		}
+	}
+
+	if cfg.Notifier == "smtp" {
+		errs = append(errs, validateSMTP(cfg.SMTP)...)
+	}
+
+	return errs
+}
+
+func validateSMTP(cfg domain.SMTPConfig) []error {
+	var errs []error
+
+	if cfg.Host == "" {
+		errs = append(errs, errors.New("smtp.host must be provided"))
+	}
+
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		errs = append(errs, fmt.Errorf("smtp.port: must be between 1 and 65535, got %d", cfg.Port))
+	}
+
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		errs = append(errs, fmt.Errorf("smtp.from: must be an email address, got %q", cfg.From))
+	}
+
+	if len(cfg.To) == 0 {
+		errs = append(errs, errors.New("smtp.to must be provided"))
+	}
+	for _, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			errs = append(errs, fmt.Errorf("smtp.to: must only contain email addresses, got %q", to))
+		}
+	}
+
+	return errs
+}
+
+// formatValidationError prints every validation error on its own line.
+func formatValidationError(err error) string {
+	return "- " + strings.ReplaceAll(err.Error(), "\n", "\n- ")
+}
+
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+func dirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// sortedKeys returns the keys of the map in order, so errors are always reported in the same order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+
+	"tcb-bot/internal/database/queries"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// Backup writes a consistent copy of the open database to destination and returns the number of
+// chapters it contains.
+func (db *DB) Backup(ctx context.Context, destination string) (int, error) {
+	if _, err := db.handler.ExecContext(ctx, `VACUUM INTO ?;`, destination); err != nil {
+		return 0, errors.Wrap(err, "could not back up database to %s", destination)
+	}
+
+	return verifyDatabase(ctx, destination)
+}
+
+// Restore replaces the database file with source and returns the number of chapters it contains.
+// The database is closed first, so it has to be opened again before it can be used.
+func (db *DB) Restore(ctx context.Context, source string) (int, error) {
+	if _, err := verifyDatabase(ctx, source); err != nil {
+		return 0, err
+	}
+
+	if err := db.Close(); err != nil {
+		return 0, errors.Wrap(err, "could not close database")
+	}
+	db.handler = nil
+	db.queries = nil
+
+	destination := db.cfg.Config.CollectedChaptersDB
+	if err := copyFile(source, destination); err != nil {
+		return 0, err
+	}
+
+	// leftover WAL files belong to the replaced database and would corrupt the restored one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(destination + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, errors.Wrap(err, "could not remove %s", destination+suffix)
+		}
+	}
+
+	return verifyDatabase(ctx, destination)
+}
+
+// verifyDatabase runs an integrity check on the database file and returns the number of chapters
+// it contains.
+func verifyDatabase(ctx context.Context, path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, errors.Wrap(err, "could not find database %s", path)
+	}
+
+	handler, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not open database %s", path)
+	}
+	defer handler.Close()
+
+	var result string
+	if err := handler.QueryRowContext(ctx, `PRAGMA integrity_check;`).Scan(&result); err != nil {
+		return 0, errors.Wrap(err, "could not check integrity of %s", path)
+	}
+	if result != "ok" {
+		return 0, errors.New("integrity check of %s failed: %s", path, result)
+	}
+
+	chapters, err := queries.New(handler).CountChapters(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not count chapters in %s", path)
+	}
+
+	return int(chapters), nil
+}
+
+func copyFile(source, destination string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return errors.Wrap(err, "could not open %s", source)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(destination)
+	if err != nil {
+		return errors.Wrap(err, "could not create %s", destination)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return errors.Wrap(err, "could not copy %s to %s", source, destination)
+	}
+
+	return dst.Sync()
+}
@@ -1,37 +1,74 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"tcb-bot/internal/config"
+	"tcb-bot/internal/database/queries"
 	"tcb-bot/internal/domain"
 	"tcb-bot/internal/logger"
+	"tcb-bot/internal/otel"
 
+	"github.com/autobrr/autobrr/pkg/errors"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 	_ "modernc.org/sqlite" // Import the SQLite driver
 )
 
+// connectionPragmas are applied to every connection of the pool, most pragmas only affect the connection they're run
+// on. WAL allows reads while the scraper is writing, busy_timeout waits for locks instead of failing. Transactions
+// take the write lock when they begin, a transaction that only tries to take it once it writes fails right away if
+// another connection wrote in the meantime.
+const connectionPragmas = "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)" +
+	"&_txlock=immediate"
+
 type DB struct {
-	log     zerolog.Logger
-	cfg     *config.AppConfig
-	handler *sql.DB
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	chapters domain.ChapterStore
+	handler  *sql.DB
+	// queries are generated by sqlc from query.sql, see sqlc.yaml. Only the queries sqlc can't generate are written by
+	// hand: PRAGMAs and VACUUM INTO, which it can't parse, the full-text search of chapters_fts, which isn't part of
+	// schema.sql, and the table creation and migrations in Open, which build the schema the queries are checked against.
+	queries *queries.Queries
 }
 
-func NewDB(log logger.Logger, cfg *config.AppConfig) *DB {
+func NewDB(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore) *DB {
 	return &DB{
-		log: log.With().Str("module", "database").Logger(),
-		cfg: cfg,
+		log:      log.With().Str("module", "database").Logger(),
+		cfg:      cfg,
+		chapters: chapters,
 	}
 }
 
 func (db *DB) Open() error {
+	// SQLite creates the database file, but not its directory
+	if db.cfg.Config.CollectedChaptersDB != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(db.cfg.Config.CollectedChaptersDB), 0o755); err != nil {
+			return errors.Wrap(err, "could not create database directory of %s", db.cfg.Config.CollectedChaptersDB)
+		}
+	}
+
 	db.log.Trace().Msg("Trying to open SQLite database")
-	database, err := sql.Open("sqlite", db.cfg.Config.CollectedChaptersDB)
+	database, err := sql.Open("sqlite", db.cfg.Config.CollectedChaptersDB+connectionPragmas)
 	if err != nil {
-		return err
+		return unavailable(err)
 	}
 	db.log.Trace().Msg("Successfully opened SQLite database")
 
+	database.SetMaxOpenConns(db.cfg.Config.DBMaxOpenConns)
+	database.SetMaxIdleConns(db.cfg.Config.DBMaxIdleConns)
+
+	// the pragmas are only applied once a connection is opened, so a broken database fails here already
+	if err := database.Ping(); err != nil {
+		return unavailable(err)
+	}
+
 	// Create table if not exists
 	_, err = database.Exec(`
         CREATE TABLE IF NOT EXISTS collected_chapters (
@@ -43,15 +80,31 @@ func (db *DB) Open() error {
             releaseTime TEXT
         );`)
 	if err != nil {
-		return err
+		return unavailable(err)
 	}
 
 	db.handler = database
+	db.queries = queries.New(database)
 
 	db.log.Trace().Msg("Successfully created table")
+
+	if err := db.runMigrations(context.Background()); err != nil {
+		return unavailable(err)
+	}
+
 	return nil
 }
 
+// unavailable marks errors of the database itself, like a missing or locked database file, so callers can tell
+// them apart from other errors using errors.Is(err, domain.ErrDatabaseUnavailable).
+func unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", domain.ErrDatabaseUnavailable, err)
+}
+
 func (db *DB) Close() error {
 	if db.handler != nil {
 		return db.handler.Close()
@@ -59,56 +112,145 @@ func (db *DB) Close() error {
 	return nil
 }
 
-func (db *DB) LoadCollectedChapters() {
+// Ping checks if the database handle is open and reachable.
+func (db *DB) Ping(ctx context.Context) error {
+	if db.handler == nil {
+		return unavailable(sql.ErrConnDone)
+	}
+	return unavailable(db.handler.PingContext(ctx))
+}
+
+func (db *DB) LoadCollectedChapters(ctx context.Context) {
 	db.log.Trace().Msg("Loading collected chapters")
-	rows, err := db.handler.Query(`SELECT releaseTitle, releaseLink, mangaTitle, chapterNumber, chapterTitle, releaseTime FROM collected_chapters;`)
+	rows, err := db.queries.ListChapters(ctx)
 	if err != nil {
 		db.log.Fatal().Err(err).Msg("Error loading collected chapters")
 		return
 	}
-	defer rows.Close()
 
-	db.log.Trace().Msg("Scanning rows")
-	for rows.Next() {
-		var releaseTitle, releaseLink, mangaTitle, chapterNumber, chapterTitle, releaseTime string
+	for _, row := range rows {
+		db.log.Trace().Str("chapter", row.ReleaseTitle).Msg("Updating collected chapters with scanned info")
+		db.chapters.Store(row.ReleaseTitle, chapterFromRow(row))
+	}
+}
 
-		if err := rows.Scan(&releaseTitle, &releaseLink, &mangaTitle, &chapterNumber, &chapterTitle, &releaseTime); err != nil {
-			db.log.Error().Err(err).Msg("Error scanning chapter row")
-			continue
-		}
+// chapterFromRow converts a row of collected_chapters into a chapter, missing values are left empty.
+func chapterFromRow(row queries.CollectedChapter) domain.ChapterInfo {
+	chapter := domain.ChapterInfo{
+		ReleaseLink:   row.ReleaseLink.String,
+		MangaTitle:    row.MangaTitle.String,
+		ChapterNumber: row.ChapterNumber.String,
+		ChapterTitle:  row.ChapterTitle.String,
+		ReleaseTime:   row.ReleaseTime.String,
+	}
+	if row.AnnouncedAt.Valid {
+		chapter.AnnouncedAt, _ = time.Parse(time.RFC3339, row.AnnouncedAt.String)
+	}
+	if row.LastNotifiedAt.Valid {
+		chapter.LastNotifiedAt, _ = time.Parse(time.RFC3339, row.LastNotifiedAt.String)
+	}
 
-		db.log.Trace().Str("chapter", releaseTitle).Msg("Updating CollectedChaptersMap with scanned info")
-		newChapter := domain.ChapterInfo{
-			ReleaseLink:   releaseLink,
-			MangaTitle:    mangaTitle,
-			ChapterNumber: chapterNumber,
-			ChapterTitle:  chapterTitle,
-			ReleaseTime:   releaseTime,
-		}
+	return chapter
+}
 
-		domain.CollectedChaptersMap.Store(releaseTitle, newChapter)
+// nullTime stores t as RFC3339 string, the zero time is stored as NULL.
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
 	}
 
-	db.log.Trace().Msg("Reading rows")
-	if err := rows.Err(); err != nil {
-		db.log.Fatal().Err(err).Msg("Error reading rows")
-	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
 }
 
-func (db *DB) SaveCollectedChapters() {
-	domain.CollectedChaptersMap.Range(func(releaseTitle, chapterInfo any) bool {
-		db.log.Trace().Str("chapter", releaseTitle.(string)).Msg("Saving collected chapter")
-		_, err := db.handler.Exec(`
-            INSERT INTO collected_chapters (releaseTitle, releaseLink, mangaTitle, chapterNumber, chapterTitle, releaseTime) 
-            VALUES (?, ?, ?, ?, ?, ?)
-            ON CONFLICT(releaseTitle) DO UPDATE 
-            SET releaseLink = excluded.releaseLink, mangaTitle = excluded.mangaTitle, chapterNumber = excluded.chapterNumber, chapterTitle = excluded.chapterTitle, releaseTime = excluded.releaseTime;`,
-			releaseTitle.(string), chapterInfo.(domain.ChapterInfo).ReleaseLink,
-			chapterInfo.(domain.ChapterInfo).MangaTitle, chapterInfo.(domain.ChapterInfo).ChapterNumber,
-			chapterInfo.(domain.ChapterInfo).ChapterTitle, chapterInfo.(domain.ChapterInfo).ReleaseTime)
-		if err != nil {
-			db.log.Fatal().Str("chapter", releaseTitle.(string)).Err(err).Msg("Error saving collected chapter")
+// SaveCollectedChapters saves every collected chapter, it stops at the first chapter that couldn't be saved.
+func (db *DB) SaveCollectedChapters(ctx context.Context) error {
+	var err error
+	db.chapters.Range(func(releaseTitle string, chapter domain.ChapterInfo) bool {
+		db.log.Trace().Str("chapter", releaseTitle).Msg("Saving collected chapter")
+		if err = db.SaveCollectedChapter(ctx, releaseTitle, chapter); err != nil {
+			err = errors.Wrap(err, "could not save collected chapter: %s", releaseTitle)
+			return false
 		}
 		return true
 	})
+
+	return err
+}
+
+func (db *DB) SaveCollectedChapter(ctx context.Context, releaseTitle string, chapter domain.ChapterInfo) (err error) {
+	ctx, span := otel.Tracer().Start(ctx, "DB.SaveCollectedChapter",
+		trace.WithAttributes(otel.ChapterAttributes(chapter.MangaTitle, chapter.ChapterNumber)...))
+	defer func() { otel.EndSpan(span, err) }()
+
+	err = db.queries.InsertChapter(ctx, queries.InsertChapterParams{
+		ReleaseTitle:   releaseTitle,
+		ReleaseLink:    sql.NullString{String: chapter.ReleaseLink, Valid: true},
+		MangaTitle:     sql.NullString{String: chapter.MangaTitle, Valid: true},
+		ChapterNumber:  sql.NullString{String: chapter.ChapterNumber, Valid: true},
+		ChapterTitle:   sql.NullString{String: chapter.ChapterTitle, Valid: true},
+		ReleaseTime:    sql.NullString{String: chapter.ReleaseTime, Valid: true},
+		AnnouncedAt:    nullTime(chapter.AnnouncedAt),
+		LastNotifiedAt: nullTime(chapter.LastNotifiedAt),
+	})
+	return unavailable(err)
+}
+
+// MarkChapterRead sets the time the chapter was read at.
+func (db *DB) MarkChapterRead(ctx context.Context, releaseTitle string, readAt time.Time) error {
+	result, err := db.queries.UpdateChapterReadAt(ctx, queries.UpdateChapterReadAtParams{
+		ReadAt:       sql.NullString{String: readAt.UTC().Format(time.RFC3339), Valid: true},
+		ReleaseTitle: releaseTitle,
+	})
+	if err != nil {
+		return unavailable(err)
+	}
+
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return errors.New("chapter not found: %s", releaseTitle)
+	}
+
+	return nil
+}
+
+// GetChapter returns the collected chapter with the release title, the error is sql.ErrNoRows if there is none.
+func (db *DB) GetChapter(ctx context.Context, releaseTitle string) (domain.ChapterInfo, error) {
+	row, err := db.queries.GetChapter(ctx, releaseTitle)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ChapterInfo{}, err
+	} else if err != nil {
+		return domain.ChapterInfo{}, unavailable(err)
+	}
+
+	return chapterFromRow(row), nil
+}
+
+func (db *DB) DeleteChapter(ctx context.Context, releaseTitle string) error {
+	_, err := db.queries.DeleteChapter(ctx, releaseTitle)
+	return unavailable(err)
+}
+
+// GetPreviousChapterTime returns the release time of the latest collected chapter of a manga
+// that comes before the given chapter number.
+func (db *DB) GetPreviousChapterTime(ctx context.Context, mangaTitle, currentChapterNumber string) (time.Time, error) {
+	releaseTime, err := db.queries.GetPreviousChapterReleaseTime(ctx, queries.GetPreviousChapterReleaseTimeParams{
+		MangaTitle:    sql.NullString{String: mangaTitle, Valid: true},
+		ChapterNumber: currentChapterNumber,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, err
+	} else if err != nil {
+		return time.Time{}, unavailable(err)
+	}
+
+	return domain.ParseReleaseTime(releaseTime.String)
+}
+
+// IsFirstChapterForManga reports whether no chapter of the manga was collected yet.
+func (db *DB) IsFirstChapterForManga(ctx context.Context, mangaTitle string) (bool, error) {
+	count, err := db.queries.CountChaptersOfManga(ctx, sql.NullString{String: mangaTitle, Valid: true})
+	if err != nil {
+		return false, unavailable(err)
+	}
+
+	return count == 0, nil
 }
@@ -0,0 +1,182 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/testutils"
+)
+
+// TestConcurrentAccess writes and reads using several connections at once, like the scraper, the API and the prune
+// command do. None of them may fail because the database is locked.
+func TestConcurrentAccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collected_chapters.db")
+	cfg := testutils.NewTestConfig(func(cfg *domain.Config) {
+		cfg.CollectedChaptersDB = path
+		cfg.DBMaxOpenConns = 8
+		cfg.DBMaxIdleConns = 8
+	})
+
+	db := database.NewDB(testutils.NewTestLogger(t), cfg, domain.NewInMemoryStore())
+	if err := db.Open(); err != nil {
+		t.Fatalf("could not open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("could not close database: %v", err)
+		}
+	})
+
+	ctx := context.Background()
+	const writers, readers, pruners, chaptersPerWriter = 4, 4, 2, 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, (writers+readers+pruners)*chaptersPerWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			mangaTitle := fmt.Sprintf("Manga %d", w)
+			for i := 1; i <= chaptersPerWriter; i++ {
+				chapter := testutils.MustParseChapter(t, fmt.Sprintf(
+					`{"manga_title": %q, "chapter_number": "%d", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/%d/manga-%d-chapter-%d"}`,
+					mangaTitle, i, w*1000+i, w, i))
+				errs <- db.SaveCollectedChapter(ctx, fmt.Sprintf("%s Chapter %d", mangaTitle, i), chapter)
+			}
+		}(w)
+	}
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < chaptersPerWriter; i++ {
+				_, err := db.ListChaptersByManga(ctx, fmt.Sprintf("Manga %d", r), 10)
+				errs <- err
+			}
+		}(r)
+	}
+	// pruning holds the write lock for a whole transaction
+	for p := 0; p < pruners; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < chaptersPerWriter; i++ {
+				_, err := db.PruneChapters(ctx, []string{"Pruned Chapter 1", "Pruned Chapter 2", "Pruned Chapter 3"})
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent access failed: %v", err)
+		}
+	}
+
+	for w := 0; w < writers; w++ {
+		chapters, err := db.ListChaptersByManga(ctx, fmt.Sprintf("Manga %d", w), chaptersPerWriter)
+		if err != nil {
+			t.Fatalf("could not list chapters: %v", err)
+		}
+		if len(chapters) != chaptersPerWriter {
+			t.Errorf("Manga %d has %d chapters, want %d", w, len(chapters), chaptersPerWriter)
+		}
+	}
+
+	// the write-ahead log only exists in WAL mode
+	if _, err := os.Stat(path + "-wal"); err != nil {
+		t.Errorf("database is not in WAL mode: %v", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatalf("could not read schema.sql: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "collected_chapters.db")
+	cfg := testutils.NewTestConfig(func(cfg *domain.Config) {
+		cfg.CollectedChaptersDB = path
+	})
+	db := database.NewDB(testutils.NewTestLogger(t), cfg, domain.NewInMemoryStore())
+	if err := db.Open(); err != nil {
+		t.Fatalf("could not open database: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("could not close database: %v", err)
+	}
+
+	migrated := openSQLite(t, path)
+	expected := openSQLite(t, ":memory:")
+	if _, err := expected.Exec(string(schema)); err != nil {
+		t.Fatalf("could not apply schema.sql: %v", err)
+	}
+
+	want := describeSchema(t, migrated)
+	got := describeSchema(t, expected)
+	for table, columns := range want {
+		if got[table] != columns {
+			t.Errorf("table %s differs from the migrations:\nschema.sql: %s\nmigrations: %s", table, got[table], columns)
+		}
+	}
+	for table := range got {
+		if _, ok := want[table]; !ok {
+			t.Errorf("table %s of schema.sql isn't created by the migrations", table)
+		}
+	}
+}
+
+func openSQLite(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("could not open %s: %v", path, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// describeSchema returns the columns of every table and the indexes, except those of chapters_fts which isn't part of
+// schema.sql.
+func describeSchema(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+
+	rows, err := db.Query(`
+            SELECT m.name, group_concat(c.name || ' ' || c.type || ' ' || c."notnull" || ' ' || c.pk, ', ')
+            FROM sqlite_master m, pragma_table_info(m.name) c
+            WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' AND m.name NOT LIKE 'chapters_fts%'
+            GROUP BY m.name
+            UNION ALL
+            SELECT name, tbl_name FROM sqlite_master
+            WHERE type = 'index' AND name NOT LIKE 'sqlite_%';`)
+	if err != nil {
+		t.Fatalf("could not describe schema: %v", err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string]string)
+	for rows.Next() {
+		var name, description string
+		if err := rows.Scan(&name, &description); err != nil {
+			t.Fatalf("could not describe schema: %v", err)
+		}
+		tables[name] = description
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("could not describe schema: %v", err)
+	}
+
+	return tables
+}
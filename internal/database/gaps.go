@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// FindGaps returns the integer chapter numbers that are missing between the lowest and the highest
+// collected chapter of the manga. Decimal chapters lie between two integers and are ignored.
+func (db *DB) FindGaps(ctx context.Context, mangaTitle string) ([]string, error) {
+	chapterNumbers, err := db.queries.ListChapterNumbersByManga(ctx, sql.NullString{String: mangaTitle, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []int
+	for _, chapterNumber := range chapterNumbers {
+		number, err := strconv.ParseFloat(chapterNumber.String, 64)
+		if err != nil || number != math.Trunc(number) {
+			continue
+		}
+		chapters = append(chapters, int(number))
+	}
+
+	slices.Sort(chapters)
+	chapters = slices.Compact(chapters)
+
+	var gaps []string
+	for i := 1; i < len(chapters); i++ {
+		for missing := chapters[i-1] + 1; missing < chapters[i]; missing++ {
+			gaps = append(gaps, strconv.Itoa(missing))
+		}
+	}
+
+	return gaps, nil
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+)
+
+// IsHiatusNotified reports whether a hiatus notification was already sent for the manga.
+func (db *DB) IsHiatusNotified(ctx context.Context, mangaTitle string) (bool, error) {
+	_, err := db.queries.GetHiatusNotification(ctx, mangaTitle)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (db *DB) SetHiatusNotified(ctx context.Context, mangaTitle string, notifiedAt time.Time) error {
+	return db.queries.UpsertHiatusNotification(ctx, queries.UpsertHiatusNotificationParams{
+		MangaTitle: mangaTitle,
+		NotifiedAt: notifiedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// ClearHiatusNotified removes the hiatus state of the manga and reports whether there was one.
+func (db *DB) ClearHiatusNotified(ctx context.Context, mangaTitle string) (bool, error) {
+	res, err := db.queries.DeleteHiatusNotification(ctx, mangaTitle)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+)
+
+// ListChaptersByManga returns up to limit collected chapters of the manga, starting with the latest.
+func (db *DB) ListChaptersByManga(ctx context.Context, mangaTitle string, limit int) ([]domain.ChapterInfo, error) {
+	rows, err := db.queries.ListChaptersByManga(ctx, queries.ListChaptersByMangaParams{
+		MangaTitle: sql.NullString{String: mangaTitle, Valid: true},
+		Limit:      int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []domain.ChapterInfo
+	for _, row := range rows {
+		chapters = append(chapters, domain.ChapterInfo{
+			ReleaseLink:   row.ReleaseLink.String,
+			MangaTitle:    row.MangaTitle.String,
+			ChapterNumber: row.ChapterNumber.String,
+			ChapterTitle:  row.ChapterTitle.String,
+			ReleaseTime:   row.ReleaseTime.String,
+		})
+	}
+
+	return chapters, nil
+}
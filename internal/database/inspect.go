@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// Stats describes the contents and health of the database.
+type Stats struct {
+	Chapters      int
+	Mangas        []domain.MangaStat
+	OldestRelease time.Time
+	NewestRelease time.Time
+	FileSize      int64
+	PageCount     int
+	FreePages     int
+	// Integrity is "ok" or the problems found by PRAGMA integrity_check
+	Integrity string
+}
+
+// OpenReadOnly opens the existing database without creating or migrating it.
+func (db *DB) OpenReadOnly() error {
+	if _, err := os.Stat(db.cfg.Config.CollectedChaptersDB); err != nil {
+		return errors.Wrap(err, "could not find database %s", db.cfg.Config.CollectedChaptersDB)
+	}
+
+	database, err := sql.Open("sqlite", "file:"+db.cfg.Config.CollectedChaptersDB+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	db.handler = database
+	db.queries = queries.New(database)
+
+	return db.handler.Ping()
+}
+
+// Stats inspects the database.
+func (db *DB) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	mangas, err := db.MangaStats(ctx)
+	if err != nil {
+		return stats, errors.Wrap(err, "could not load manga stats")
+	}
+	stats.Mangas = mangas
+
+	for _, manga := range mangas {
+		stats.Chapters += manga.ChapterCount
+		if !manga.FirstChapterTime.IsZero() &&
+			(stats.OldestRelease.IsZero() || manga.FirstChapterTime.Before(stats.OldestRelease)) {
+			stats.OldestRelease = manga.FirstChapterTime
+		}
+		if manga.LatestChapterTime.After(stats.NewestRelease) {
+			stats.NewestRelease = manga.LatestChapterTime
+		}
+	}
+
+	info, err := os.Stat(db.cfg.Config.CollectedChaptersDB)
+	if err != nil {
+		return stats, errors.Wrap(err, "could not get size of database")
+	}
+	stats.FileSize = info.Size()
+
+	if err := db.handler.QueryRowContext(ctx, `PRAGMA page_count;`).Scan(&stats.PageCount); err != nil {
+		return stats, errors.Wrap(err, "could not get page count")
+	}
+	if err := db.handler.QueryRowContext(ctx, `PRAGMA freelist_count;`).Scan(&stats.FreePages); err != nil {
+		return stats, errors.Wrap(err, "could not get free pages")
+	}
+
+	// integrity_check returns one row per problem
+	rows, err := db.handler.QueryContext(ctx, `PRAGMA integrity_check;`)
+	if err != nil {
+		return stats, errors.Wrap(err, "could not check integrity")
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return stats, err
+		}
+		problems = append(problems, problem)
+	}
+	stats.Integrity = strings.Join(problems, "\n")
+
+	return stats, rows.Err()
+}
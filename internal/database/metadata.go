@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+)
+
+// GetMangaMetadata returns the cached metadata of a manga, sql.ErrNoRows is returned if there is none.
+func (db *DB) GetMangaMetadata(ctx context.Context, mangaTitle string) (domain.MangaMetadata, error) {
+	row, err := db.queries.GetMangaMetadata(ctx, mangaTitle)
+	if err != nil {
+		return domain.MangaMetadata{}, err
+	}
+
+	return domain.MangaMetadata{
+		MangaTitle: mangaTitle,
+		CoverImage: row.CoverImage.String,
+		Synopsis:   row.Synopsis.String,
+		Status:     row.Status.String,
+	}, nil
+}
+
+func (db *DB) SaveMangaMetadata(ctx context.Context, metadata domain.MangaMetadata) error {
+	return db.queries.UpsertMangaMetadata(ctx, queries.UpsertMangaMetadataParams{
+		MangaTitle: metadata.MangaTitle,
+		CoverImage: sql.NullString{String: metadata.CoverImage, Valid: true},
+		Synopsis:   sql.NullString{String: metadata.Synopsis, Valid: true},
+		Status:     sql.NullString{String: metadata.Status, Valid: true},
+		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// migrations are applied in order and exactly once per database. Existing entries must
+// never be changed or reordered, schema changes are always appended as a new entry.
+var migrations = []string{
+	// baseline, collected_chapters is created by the initial schema
+	``,
+	`CREATE TABLE hiatus_notifications (
+            mangaTitle TEXT PRIMARY KEY,
+            notifiedAt TEXT NOT NULL
+        );`,
+	`CREATE TABLE manga_metadata (
+            mangaTitle TEXT PRIMARY KEY,
+            coverImage TEXT,
+            synopsis TEXT,
+            status TEXT,
+            updatedAt TEXT NOT NULL
+        );`,
+	// chapters collected before announcedAt existed have already been announced
+	`ALTER TABLE collected_chapters ADD COLUMN announcedAt TEXT;
+        UPDATE collected_chapters SET announcedAt = strftime('%Y-%m-%dT%H:%M:%SZ', 'now');`,
+	`CREATE TABLE bot_state (
+            id INTEGER PRIMARY KEY CHECK (id = 1),
+            lastError TEXT NOT NULL,
+            lastErrorTime TEXT NOT NULL,
+            circuitState TEXT NOT NULL
+        );`,
+	`CREATE INDEX idx_collected_chapters_manga_release ON collected_chapters (mangaTitle, releaseTime);`,
+	`ALTER TABLE collected_chapters ADD COLUMN readAt TEXT;`,
+	`ALTER TABLE collected_chapters ADD COLUMN lastNotifiedAt TEXT;`,
+	`CREATE TABLE notification_queue (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            chapter_release_title TEXT NOT NULL UNIQUE,
+            attempt_count INTEGER NOT NULL DEFAULT 0,
+            next_attempt_at TEXT NOT NULL,
+            created_at TEXT NOT NULL
+        );`,
+	// chapters_fts indexes the titles of collected_chapters for /search, the triggers keep it in sync
+	`CREATE VIRTUAL TABLE chapters_fts USING fts5(releaseTitle, chapterTitle, content=collected_chapters);
+        CREATE TRIGGER collected_chapters_fts_insert AFTER INSERT ON collected_chapters BEGIN
+            INSERT INTO chapters_fts (rowid, releaseTitle, chapterTitle)
+            VALUES (new.rowid, new.releaseTitle, new.chapterTitle);
+        END;
+        CREATE TRIGGER collected_chapters_fts_delete AFTER DELETE ON collected_chapters BEGIN
+            INSERT INTO chapters_fts (chapters_fts, rowid, releaseTitle, chapterTitle)
+            VALUES ('delete', old.rowid, old.releaseTitle, old.chapterTitle);
+        END;
+        CREATE TRIGGER collected_chapters_fts_update AFTER UPDATE OF releaseTitle, chapterTitle ON collected_chapters
+        BEGIN
+            INSERT INTO chapters_fts (chapters_fts, rowid, releaseTitle, chapterTitle)
+            VALUES ('delete', old.rowid, old.releaseTitle, old.chapterTitle);
+            INSERT INTO chapters_fts (rowid, releaseTitle, chapterTitle)
+            VALUES (new.rowid, new.releaseTitle, new.chapterTitle);
+        END;
+        INSERT INTO chapters_fts (chapters_fts) VALUES ('rebuild');`,
+	// errors are kept per manga, the circuit state was never restored on startup
+	`DROP TABLE bot_state;
+        CREATE TABLE manga_errors (
+            mangaTitle TEXT PRIMARY KEY,
+            lastError TEXT NOT NULL,
+            lastErrorTime TEXT NOT NULL
+        );`,
+	// the last error and the circuit state are kept in bot_state again, the errors of the mangas only until a restart
+	`DROP TABLE manga_errors;
+        CREATE TABLE bot_state (
+            id INTEGER PRIMARY KEY CHECK (id = 1),
+            lastError TEXT NOT NULL,
+            lastErrorTime TEXT NOT NULL,
+            circuitState TEXT NOT NULL
+        );`,
+	// notifiers holds the names of the notifiers that still have to send a queued notification, empty for all of them
+	`ALTER TABLE notification_queue ADD COLUMN notifiers TEXT NOT NULL DEFAULT '';`,
+}
+
+func (db *DB) runMigrations(ctx context.Context) error {
+	db.log.Trace().Msg("Running database migrations")
+	_, err := db.handler.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS migrations (
+            version INTEGER PRIMARY KEY,
+            appliedAt TEXT NOT NULL
+        );`)
+	if err != nil {
+		return errors.Wrap(err, "could not create migrations table")
+	}
+
+	var version int
+	if err := db.handler.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM migrations;`).Scan(&version); err != nil {
+		return errors.Wrap(err, "could not get current schema version")
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := db.applyMigration(ctx, i+1, migrations[i]); err != nil {
+			return errors.Wrap(err, "could not apply migration %d", i+1)
+		}
+		db.log.Debug().Msgf("Applied database migration %d", i+1)
+	}
+
+	if err := db.migrateReleaseTimes(ctx); err != nil {
+		return errors.Wrap(err, "could not migrate release times")
+	}
+
+	return nil
+}
+
+// migrateReleaseTimes converts release times stored by older releases into domain.ReleaseTimeFormat. It can't be a
+// migration because SQLite can't parse them, release times that can't be parsed are kept as they are.
+func (db *DB) migrateReleaseTimes(ctx context.Context) error {
+	rows, err := db.queries.ListUnconvertedReleaseTimes(ctx)
+	if err != nil {
+		return err
+	}
+
+	releaseTimes := make(map[string]string)
+	for _, row := range rows {
+		released, err := domain.ParseReleaseTime(row.ReleaseTime.String)
+		if err != nil {
+			db.log.Warn().Err(err).Msgf("error parsing release time, keeping it: %q", row.ReleaseTitle)
+			continue
+		}
+		releaseTimes[row.ReleaseTitle] = domain.FormatReleaseTime(released)
+	}
+
+	if len(releaseTimes) == 0 {
+		return nil
+	}
+
+	tx, err := db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := db.queries.WithTx(tx)
+	for releaseTitle, releaseTime := range releaseTimes {
+		if err := qtx.UpdateChapterReleaseTime(ctx, queries.UpdateChapterReleaseTimeParams{
+			ReleaseTime:  sql.NullString{String: releaseTime, Valid: true},
+			ReleaseTitle: releaseTitle,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	db.log.Info().Msgf("Converted the release times of %d chapters", len(releaseTimes))
+	return nil
+}
+
+// applyMigration runs a single migration inside a transaction so a failing migration
+// never leaves the schema in a partial state.
+func (db *DB) applyMigration(ctx context.Context, version int, migration string) error {
+	tx, err := db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if migration != "" {
+		if _, err := tx.ExecContext(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO migrations (version, appliedAt) VALUES (?, ?);`,
+		version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"tcb-bot/internal/domain"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// ChaptersReleasedBefore returns the release titles of all collected chapters released before cutoff.
+// Chapters with a release time that can't be parsed are never returned.
+func (db *DB) ChaptersReleasedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := db.queries.ListReleaseTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var releaseTitles []string
+	for _, row := range rows {
+		released, err := domain.ParseReleaseTime(row.ReleaseTime.String)
+		if err != nil {
+			db.log.Warn().Err(err).Msgf("error parsing release time, keeping chapter: %q", row.ReleaseTitle)
+			continue
+		}
+
+		if released.Before(cutoff) {
+			releaseTitles = append(releaseTitles, row.ReleaseTitle)
+		}
+	}
+
+	return releaseTitles, nil
+}
+
+// PruneChapters deletes the given chapters in a single transaction and optimizes the database afterwards.
+// It returns the number of deleted chapters.
+func (db *DB) PruneChapters(ctx context.Context, releaseTitles []string) (int, error) {
+	tx, err := db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not begin transaction")
+	}
+	defer tx.Rollback()
+
+	qtx := db.queries.WithTx(tx)
+	var deleted int
+	for _, releaseTitle := range releaseTitles {
+		result, err := qtx.DeleteChapter(ctx, releaseTitle)
+		if err != nil {
+			return 0, errors.Wrap(err, "could not delete chapter %q", releaseTitle)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "could not commit transaction")
+	}
+
+	if _, err := db.handler.ExecContext(ctx, `PRAGMA optimize;`); err != nil {
+		return deleted, errors.Wrap(err, "could not optimize database")
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,108 @@
+-- name: ListChapters :many
+SELECT * FROM collected_chapters;
+
+-- name: GetChapter :one
+SELECT * FROM collected_chapters
+WHERE releaseTitle = ?;
+
+-- name: InsertChapter :exec
+INSERT INTO collected_chapters (releaseTitle, releaseLink, mangaTitle, chapterNumber, chapterTitle, releaseTime, announcedAt, lastNotifiedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(releaseTitle) DO UPDATE
+SET releaseLink = excluded.releaseLink, mangaTitle = excluded.mangaTitle, chapterNumber = excluded.chapterNumber, chapterTitle = excluded.chapterTitle, releaseTime = excluded.releaseTime, announcedAt = excluded.announcedAt, lastNotifiedAt = excluded.lastNotifiedAt;
+
+-- name: DeleteChapter :execresult
+DELETE FROM collected_chapters
+WHERE releaseTitle = ?;
+
+-- name: UpdateChapterReadAt :execresult
+UPDATE collected_chapters SET readAt = ?
+WHERE releaseTitle = ?;
+
+-- name: UpdateChapterReleaseTime :exec
+UPDATE collected_chapters SET releaseTime = ?
+WHERE releaseTitle = ?;
+
+-- name: CountChapters :one
+SELECT COUNT(*) FROM collected_chapters;
+
+-- name: CountChaptersOfManga :one
+SELECT COUNT(*) FROM collected_chapters
+WHERE mangaTitle = ?;
+
+-- name: CountChaptersByManga :many
+SELECT mangaTitle, COUNT(*) AS chapterCount FROM collected_chapters
+GROUP BY mangaTitle
+ORDER BY chapterCount DESC, mangaTitle;
+
+-- name: GetPreviousChapterReleaseTime :one
+SELECT releaseTime FROM collected_chapters
+WHERE mangaTitle = ? AND CAST(chapterNumber AS REAL) < CAST(sqlc.arg(chapterNumber) AS REAL)
+ORDER BY CAST(chapterNumber AS REAL) DESC
+LIMIT 1;
+
+-- name: ListChaptersByManga :many
+SELECT releaseLink, mangaTitle, chapterNumber, chapterTitle, releaseTime FROM collected_chapters
+WHERE mangaTitle = ? COLLATE NOCASE
+ORDER BY CAST(chapterNumber AS REAL) DESC
+LIMIT ?;
+
+-- name: ListChapterNumbersByManga :many
+SELECT chapterNumber FROM collected_chapters
+WHERE mangaTitle = ?;
+
+-- name: ListReleaseTimes :many
+SELECT releaseTitle, releaseTime FROM collected_chapters;
+
+-- name: ListUnconvertedReleaseTimes :many
+SELECT releaseTitle, releaseTime FROM collected_chapters
+WHERE releaseTime NOT LIKE '____-__-__T__:__:__Z';
+
+-- name: GetHiatusNotification :one
+SELECT notifiedAt FROM hiatus_notifications
+WHERE mangaTitle = ?;
+
+-- name: UpsertHiatusNotification :exec
+INSERT INTO hiatus_notifications (mangaTitle, notifiedAt) VALUES (?, ?)
+ON CONFLICT(mangaTitle) DO UPDATE SET notifiedAt = excluded.notifiedAt;
+
+-- name: DeleteHiatusNotification :execresult
+DELETE FROM hiatus_notifications
+WHERE mangaTitle = ?;
+
+-- name: GetMangaMetadata :one
+SELECT coverImage, synopsis, status FROM manga_metadata
+WHERE mangaTitle = ?;
+
+-- name: UpsertMangaMetadata :exec
+INSERT INTO manga_metadata (mangaTitle, coverImage, synopsis, status, updatedAt)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(mangaTitle) DO UPDATE
+SET coverImage = excluded.coverImage, synopsis = excluded.synopsis, status = excluded.status, updatedAt = excluded.updatedAt;
+
+-- name: InsertQueuedNotification :exec
+INSERT INTO notification_queue (chapter_release_title, attempt_count, next_attempt_at, created_at, notifiers)
+VALUES (?, 0, ?, ?, ?)
+ON CONFLICT(chapter_release_title) DO NOTHING;
+
+-- name: ListDueNotifications :many
+SELECT id, chapter_release_title, attempt_count, next_attempt_at, created_at, notifiers FROM notification_queue
+WHERE next_attempt_at <= ?
+ORDER BY created_at, id;
+
+-- name: UpdateQueuedNotification :exec
+UPDATE notification_queue SET attempt_count = ?, next_attempt_at = ?, notifiers = ?
+WHERE id = ?;
+
+-- name: DeleteQueuedNotification :exec
+DELETE FROM notification_queue
+WHERE id = ?;
+
+-- name: GetBotState :one
+SELECT lastError, lastErrorTime, circuitState FROM bot_state
+WHERE id = 1;
+
+-- name: UpsertBotState :exec
+INSERT INTO bot_state (id, lastError, lastErrorTime, circuitState) VALUES (1, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE
+SET lastError = excluded.lastError, lastErrorTime = excluded.lastErrorTime, circuitState = excluded.circuitState;
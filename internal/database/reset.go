@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// resetColumns are written to the backup of reset chapters, in this order.
+var resetColumns = []string{"releaseTitle", "releaseLink", "mangaTitle", "chapterNumber", "chapterTitle", "releaseTime",
+	"announcedAt", "readAt", "lastNotifiedAt"}
+
+// ResetChapters deletes all collected chapters, or only those of the manga if mangaTitle isn't empty, so they're
+// announced again by the next check. The deleted rows are written to a CSV file next to the database first, its
+// path is returned together with the number of deleted chapters.
+func (db *DB) ResetChapters(ctx context.Context, mangaTitle string) (int, string, error) {
+	rows, err := db.queries.ListChapters(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var records [][]string
+	for _, row := range rows {
+		if mangaTitle != "" && !utils.TitleMatches(row.MangaTitle.String, mangaTitle, false) {
+			continue
+		}
+
+		records = append(records, []string{row.ReleaseTitle, row.ReleaseLink.String, row.MangaTitle.String,
+			row.ChapterNumber.String, row.ChapterTitle.String, row.ReleaseTime.String, row.AnnouncedAt.String,
+			row.ReadAt.String, row.LastNotifiedAt.String})
+	}
+
+	if len(records) == 0 {
+		return 0, "", nil
+	}
+
+	backupPath := filepath.Join(filepath.Dir(db.cfg.Config.CollectedChaptersDB),
+		fmt.Sprintf("collected_chapters-reset-%s.csv", time.Now().Format("20060102-150405.000")))
+	if err := writeCSV(backupPath, append([][]string{resetColumns}, records...)); err != nil {
+		return 0, "", errors.Wrap(err, "could not back up chapters")
+	}
+
+	tx, err := db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, backupPath, errors.Wrap(err, "could not begin transaction")
+	}
+	defer tx.Rollback()
+
+	qtx := db.queries.WithTx(tx)
+	var deleted int
+	for _, record := range records {
+		result, err := qtx.DeleteChapter(ctx, record[0])
+		if err != nil {
+			return 0, backupPath, errors.Wrap(err, "could not delete chapter %q", record[0])
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, backupPath, err
+		}
+		deleted += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, backupPath, errors.Wrap(err, "could not commit transaction")
+	}
+
+	for _, record := range records {
+		db.chapters.Delete(record[0])
+	}
+
+	return deleted, backupPath, nil
+}
+
+func writeCSV(path string, records [][]string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+)
+
+// QueueNotification queues the notification of the chapter to be retried at nextAttemptAt by the notifiers, or by all
+// notifiers if there are none. A chapter that is already queued keeps its attempts.
+func (db *DB) QueueNotification(ctx context.Context, releaseTitle string, nextAttemptAt time.Time,
+	notifiers []string) error {
+	err := db.queries.InsertQueuedNotification(ctx, queries.InsertQueuedNotificationParams{
+		ChapterReleaseTitle: releaseTitle,
+		NextAttemptAt:       nextAttemptAt.UTC().Format(time.RFC3339),
+		CreatedAt:           time.Now().UTC().Format(time.RFC3339),
+		Notifiers:           strings.Join(notifiers, ","),
+	})
+	return unavailable(err)
+}
+
+// DueNotifications returns the queued notifications that should be retried at now, oldest first.
+func (db *DB) DueNotifications(ctx context.Context, now time.Time) ([]domain.QueuedNotification, error) {
+	rows, err := db.queries.ListDueNotifications(ctx, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, unavailable(err)
+	}
+
+	var queued []domain.QueuedNotification
+	for _, row := range rows {
+		notification := domain.QueuedNotification{
+			ID:           row.ID,
+			ReleaseTitle: row.ChapterReleaseTitle,
+			AttemptCount: int(row.AttemptCount),
+		}
+		notification.NextAttemptAt, _ = time.Parse(time.RFC3339, row.NextAttemptAt)
+		notification.CreatedAt, _ = time.Parse(time.RFC3339, row.CreatedAt)
+		if row.Notifiers != "" {
+			notification.Notifiers = strings.Split(row.Notifiers, ",")
+		}
+
+		queued = append(queued, notification)
+	}
+
+	return queued, nil
+}
+
+// RescheduleNotification stores another failed attempt of the queued notification and the notifiers that still have
+// to send it.
+func (db *DB) RescheduleNotification(ctx context.Context, id int64, attemptCount int, nextAttemptAt time.Time,
+	notifiers []string) error {
+	err := db.queries.UpdateQueuedNotification(ctx, queries.UpdateQueuedNotificationParams{
+		AttemptCount:  int64(attemptCount),
+		NextAttemptAt: nextAttemptAt.UTC().Format(time.RFC3339),
+		Notifiers:     strings.Join(notifiers, ","),
+		ID:            id,
+	})
+	return unavailable(err)
+}
+
+// DeleteQueuedNotification removes the notification from the queue, after it was sent or given up on.
+func (db *DB) DeleteQueuedNotification(ctx context.Context, id int64) error {
+	return unavailable(db.queries.DeleteQueuedNotification(ctx, id))
+}
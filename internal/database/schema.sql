@@ -0,0 +1,51 @@
+-- The schema of a database with all migrations applied, sqlc generates its code from it. The database itself is
+-- created by the migrations in migrations.go, every migration has to be reflected here as well.
+-- chapters_fts and its triggers are left out, they're only queried by hand-written queries.
+
+CREATE TABLE collected_chapters (
+    releaseTitle TEXT PRIMARY KEY,
+    releaseLink TEXT,
+    mangaTitle TEXT,
+    chapterNumber TEXT,
+    chapterTitle TEXT,
+    releaseTime TEXT,
+    announcedAt TEXT,
+    readAt TEXT,
+    lastNotifiedAt TEXT
+);
+
+CREATE INDEX idx_collected_chapters_manga_release ON collected_chapters (mangaTitle, releaseTime);
+
+CREATE TABLE migrations (
+    version INTEGER PRIMARY KEY,
+    appliedAt TEXT NOT NULL
+);
+
+CREATE TABLE hiatus_notifications (
+    mangaTitle TEXT PRIMARY KEY,
+    notifiedAt TEXT NOT NULL
+);
+
+CREATE TABLE manga_metadata (
+    mangaTitle TEXT PRIMARY KEY,
+    coverImage TEXT,
+    synopsis TEXT,
+    status TEXT,
+    updatedAt TEXT NOT NULL
+);
+
+CREATE TABLE notification_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chapter_release_title TEXT NOT NULL UNIQUE,
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    notifiers TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE bot_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    lastError TEXT NOT NULL,
+    lastErrorTime TEXT NOT NULL,
+    circuitState TEXT NOT NULL
+);
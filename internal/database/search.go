@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"tcb-bot/internal/domain"
+)
+
+// SearchChapters returns up to limit collected chapters whose release title or chapter title contains every word of
+// query, a word also matches longer words starting with it. The best matches come first.
+func (db *DB) SearchChapters(ctx context.Context, query string, limit int) ([]domain.CollectedChapter, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := db.handler.QueryContext(ctx, `
+            SELECT c.releaseTitle, c.releaseLink, c.mangaTitle, c.chapterNumber, c.chapterTitle, c.releaseTime
+            FROM chapters_fts
+            JOIN collected_chapters c ON c.rowid = chapters_fts.rowid
+            WHERE chapters_fts MATCH ?
+            ORDER BY rank
+            LIMIT ?;`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chapters []domain.CollectedChapter
+	for rows.Next() {
+		var chapter domain.CollectedChapter
+		if err := rows.Scan(&chapter.ReleaseTitle, &chapter.ReleaseLink, &chapter.MangaTitle, &chapter.ChapterNumber,
+			&chapter.ChapterTitle, &chapter.ReleaseTime); err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	return chapters, rows.Err()
+}
+
+// ftsQuery turns every word of query into a quoted prefix query, so the FTS5 query syntax can't be used to break
+// the search.
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"tcb-bot/internal/database/queries"
+	"tcb-bot/internal/domain"
+)
+
+// LoadBotState returns the persisted bot state or an empty state if none was saved yet.
+func (db *DB) LoadBotState(ctx context.Context) (domain.BotState, error) {
+	row, err := db.queries.GetBotState(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.BotState{}, nil
+	}
+	if err != nil {
+		return domain.BotState{}, unavailable(err)
+	}
+
+	state := domain.BotState{
+		LastError:    row.LastError,
+		CircuitState: row.CircuitState,
+	}
+	if row.LastErrorTime != "" {
+		state.LastErrorTime, _ = time.Parse(time.RFC3339, row.LastErrorTime)
+	}
+
+	return state, nil
+}
+
+// SaveBotState upserts the single row of bot_state.
+func (db *DB) SaveBotState(ctx context.Context, state domain.BotState) error {
+	var lastErrorTime string
+	if !state.LastErrorTime.IsZero() {
+		lastErrorTime = state.LastErrorTime.UTC().Format(time.RFC3339)
+	}
+
+	err := db.queries.UpsertBotState(ctx, queries.UpsertBotStateParams{
+		LastError:     state.LastError,
+		LastErrorTime: lastErrorTime,
+		CircuitState:  state.CircuitState,
+	})
+	return unavailable(err)
+}
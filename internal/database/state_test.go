@@ -0,0 +1,42 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/testutils"
+)
+
+func TestBotState(t *testing.T) {
+	db := testutils.NewTestDB(t)
+	ctx := context.Background()
+
+	state, err := db.LoadBotState(ctx)
+	if err != nil {
+		t.Fatalf("LoadBotState() returned an error: %v", err)
+	}
+	if state != (domain.BotState{}) {
+		t.Errorf("LoadBotState() = %+v before anything was saved, want an empty state", state)
+	}
+
+	saved := []domain.BotState{
+		{LastError: "Unexpected error occurred: timeout", LastErrorTime: time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC), CircuitState: "open"},
+		// the row is upserted, the error is cleared once no manga is failing anymore
+		{CircuitState: "closed"},
+	}
+	for _, want := range saved {
+		if err := db.SaveBotState(ctx, want); err != nil {
+			t.Fatalf("SaveBotState() returned an error: %v", err)
+		}
+
+		got, err := db.LoadBotState(ctx)
+		if err != nil {
+			t.Fatalf("LoadBotState() returned an error: %v", err)
+		}
+		if got != want {
+			t.Errorf("LoadBotState() = %+v, want %+v", got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+
+	"tcb-bot/internal/domain"
+)
+
+// MangaStats returns the chapter statistics of every manga in the database, sorted by chapter count descending.
+func (db *DB) MangaStats(ctx context.Context) ([]domain.MangaStat, error) {
+	counts, err := db.queries.CountChaptersByManga(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.MangaStat, 0, len(counts))
+	stats := make(map[string]*domain.MangaStat, len(counts))
+	for _, count := range counts {
+		result = append(result, domain.MangaStat{MangaTitle: count.MangaTitle.String, ChapterCount: int(count.ChapterCount)})
+		stats[count.MangaTitle.String] = &result[len(result)-1]
+	}
+
+	// release times stored by older releases don't sort chronologically, so they're compared after parsing
+	rows, err := db.queries.ListChapters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		stat, ok := stats[row.MangaTitle.String]
+		if !ok {
+			continue
+		}
+
+		released, err := domain.ParseReleaseTime(row.ReleaseTime.String)
+		if err != nil {
+			db.log.Error().Err(err).Msgf("error parsing release time: %q", row.ReleaseTime.String)
+			continue
+		}
+		if stat.FirstChapterTime.IsZero() || released.Before(stat.FirstChapterTime) {
+			stat.FirstChapterTime = released
+		}
+		if released.After(stat.LatestChapterTime) {
+			stat.LatestChapterTime = released
+			stat.LatestChapterNumber = row.ChapterNumber.String
+		}
+	}
+
+	return result, nil
+}
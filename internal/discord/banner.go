@@ -0,0 +1,58 @@
+package discord
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+const bannerCheckTimeout = 5 * time.Second
+
+// CheckBanners warns about manga banners that Discord can't show, because they can't be reached or aren't images.
+func CheckBanners(log logger.Logger, cfg *config.AppConfig) {
+	bannerLog := log.With().Str("module", "discord-banner").Logger()
+	client := utils.NewHTTPClient(cfg.Config.ScrapeProxyURL, bannerCheckTimeout)
+
+	mangaTitles := make([]string, 0, len(cfg.Config.MangaBanners))
+	for mangaTitle := range cfg.Config.MangaBanners {
+		mangaTitles = append(mangaTitles, mangaTitle)
+	}
+	slices.Sort(mangaTitles)
+
+	for _, mangaTitle := range mangaTitles {
+		bannerURL := cfg.Config.MangaBanners[mangaTitle]
+		if err := checkImageURL(client, bannerURL); err != nil {
+			bannerLog.Warn().Err(err).Msgf("Banner of %q can't be shown: %s", mangaTitle, bannerURL)
+		}
+	}
+}
+
+// checkImageURL makes sure the url can be reached and points to an image.
+func checkImageURL(client *http.Client, imageURL string) error {
+	if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
+		return errors.New("url must start with http:// or https://")
+	}
+
+	resp, err := client.Head(imageURL)
+	if err != nil {
+		return errors.Wrap(err, "could not reach url")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("url returned status code %d", resp.StatusCode)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return errors.New("url returned content type %q instead of an image", contentType)
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/bwmarrin/discordgo"
+)
+
+const checkCooldown = 60 * time.Second
+
+var manageServerPermission int64 = discordgo.PermissionManageServer
+
+var historyMinLimit float64 = 1
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "check",
+		Description: "Check for new chapters right now",
+	},
+	{
+		Name:        "status",
+		Description: "Show the status of the bot",
+	},
+	{
+		Name:        "ping",
+		Description: "Check if the bot is responsive",
+	},
+	{
+		Name:        "history",
+		Description: "Show the announced chapters of a manga",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "manga",
+				Description: "Title of the manga",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "limit",
+				Description: "Number of chapters to show",
+				MinValue:    &historyMinLimit,
+				MaxValue:    historyMaxLimit,
+			},
+		},
+	},
+	{
+		Name:        "search",
+		Description: "Search the announced chapters by release title or chapter title",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "Keywords to search for",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:                     "watchlist",
+		Description:              "Import or export the watched mangas",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Export the watched mangas as JSON file",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "import",
+				Description: "Import watched mangas from a JSON file",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Name:        "file",
+						Description: "Exported watchlist file",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "replace",
+						Description: "Replace the watched mangas instead of merging them",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "cover",
+		Description:              "Manage the covers of chapter notifications",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set the cover of a manga",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "manga",
+						Description: "Title of the manga",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "url",
+						Description: "URL of the cover image",
+						Required:    true,
+					},
+				},
+			},
+		},
+	},
+}
+
+// CheckFunc runs a single check for new chapters and returns how many were found.
+type CheckFunc func(ctx context.Context) (int, error)
+
+// SetCheckFunc sets the function that is run by the /check command.
+func (bot *Bot) SetCheckFunc(fn CheckFunc) {
+	bot.checkFunc = fn
+}
+
+// RegisterCommands registers the slash commands in every configured guild or globally if there are none.
+func (bot *Bot) RegisterCommands() error {
+	if len(bot.cfg.Config.Guilds) == 0 {
+		_, err := bot.discord.ApplicationCommandBulkOverwrite(bot.discord.State.User.ID, "", commands)
+		return err
+	}
+
+	for _, guild := range bot.cfg.Config.Guilds {
+		if guild.GuildID == "" {
+			continue
+		}
+		if _, err := bot.discord.ApplicationCommandBulkOverwrite(bot.discord.State.User.ID, guild.GuildID,
+			commands); err != nil {
+			return errors.Wrap(err, "could not register commands in guild %s", guild.GuildID)
+		}
+	}
+
+	return nil
+}
+
+func (bot *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		switch customID := i.MessageComponentData().CustomID; {
+		case strings.HasPrefix(customID, historyButtonPrefix):
+			bot.handleHistoryButton(s, i)
+		case strings.HasPrefix(customID, readButtonPrefix):
+			bot.handleReadButton(s, i)
+		}
+		return
+	}
+
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	switch name := i.ApplicationCommandData().Name; name {
+	case "check":
+		bot.handleCheck(s, i)
+	case "watchlist":
+		bot.handleWatchlist(s, i)
+	case "cover":
+		bot.handleCover(s, i)
+	case "status":
+		bot.handleStatus(s, i)
+	case "ping":
+		bot.handlePing(s, i)
+	case "history":
+		bot.handleHistory(s, i)
+	case "search":
+		bot.handleSearch(s, i)
+	default:
+		bot.log.Warn().Msgf("Received unknown command: %q", name)
+	}
+}
+
+func (bot *Bot) handleCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if bot.checkFunc == nil {
+		bot.respondEphemeral(s, i, "Checking isn't available right now.")
+		return
+	}
+
+	if remaining := bot.checkCooldownRemaining(i.GuildID); remaining > 0 {
+		bot.respondEphemeral(s, i, fmt.Sprintf("Please wait %d seconds before checking again.",
+			int(remaining.Seconds())+1))
+		return
+	}
+
+	bot.respondEphemeral(s, i, "Checking now…")
+
+	bot.log.Debug().Msg("Running check triggered by /check command")
+	found, err := bot.checkFunc(context.Background())
+
+	var content string
+	switch {
+	case err != nil:
+		bot.log.Error().Err(err).Msg("error running check triggered by /check command")
+		content = fmt.Sprintf("Error checking for new chapters: %v", err)
+	case found == 0:
+		content = "No new chapters found."
+	case found == 1:
+		content = "Found 1 new chapter."
+	default:
+		content = fmt.Sprintf("Found %d new chapters.", found)
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		bot.log.Error().Err(err).Msg("error editing interaction response")
+	}
+}
+
+// checkCooldownRemaining returns how long the guild has to wait before it can check again. If
+// there is no cooldown left, a new one is started.
+func (bot *Bot) checkCooldownRemaining(guildID string) time.Duration {
+	bot.m.Lock()
+	defer bot.m.Unlock()
+
+	if remaining := checkCooldown - time.Since(bot.lastChecks[guildID]); remaining > 0 {
+		return remaining
+	}
+
+	bot.lastChecks[guildID] = time.Now()
+	return 0
+}
+
+func (bot *Bot) respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
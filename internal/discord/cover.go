@@ -0,0 +1,69 @@
+package discord
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (bot *Bot) handleCover(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 || options[0].Name != "set" {
+		return
+	}
+
+	var mangaTitle, coverURL string
+	for _, option := range options[0].Options {
+		switch option.Name {
+		case "manga":
+			mangaTitle = strings.TrimSpace(option.StringValue())
+		case "url":
+			coverURL = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	// checking the url can take up to three seconds, which is all the time discord gives us to respond
+	bot.respondEphemeral(s, i, "Checking cover…")
+
+	content := fmt.Sprintf("Cover of %s has been updated.", mangaTitle)
+	if err := bot.validateCoverURL(coverURL); err != nil {
+		bot.log.Debug().Err(err).Msgf("invalid cover url: %q", coverURL)
+		content = fmt.Sprintf("Invalid cover url: %v", err)
+	} else if err := bot.cfg.SetMangaCover(mangaTitle, coverURL); err != nil {
+		bot.log.Error().Err(err).Msgf("error saving cover: %q", mangaTitle)
+		content = fmt.Sprintf("Error saving cover: %v", err)
+	} else {
+		bot.log.Info().Msgf("Updated cover of %q: %s", mangaTitle, coverURL)
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		bot.log.Error().Err(err).Msg("error editing interaction response")
+	}
+}
+
+// validateCoverURL makes sure the cover url can be reached.
+func (bot *Bot) validateCoverURL(coverURL string) error {
+	if !strings.HasPrefix(coverURL, "http://") && !strings.HasPrefix(coverURL, "https://") {
+		return errors.New("url must start with http:// or https://")
+	}
+
+	client := utils.NewHTTPClient(bot.cfg.Config.ScrapeProxyURL, 3*time.Second)
+
+	resp, err := client.Head(coverURL)
+	if err != nil {
+		return errors.Wrap(err, "could not reach url")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("url returned status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -1,26 +1,63 @@
 package discord
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
 	"tcb-bot/internal/logger"
+	"tcb-bot/internal/metrics"
+	"tcb-bot/internal/otel"
+	"tcb-bot/internal/state"
+	"tcb-bot/internal/utils"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Bot struct {
-	log     zerolog.Logger
-	cfg     *config.AppConfig
-	discord *discordgo.Session
+	log          zerolog.Logger
+	cfg          *config.AppConfig
+	chapters     domain.ChapterStore
+	discord      *discordgo.Session
+	limiter      *RateLimiter
+	checkFunc    CheckFunc
+	historyFunc  HistoryFunc
+	statsFunc    StatsFunc
+	markReadFunc MarkReadFunc
+	searchFunc   SearchFunc
+
+	m              sync.Mutex
+	lastChecks     map[string]time.Time
+	disconnectedAt time.Time
+	reconnecting   bool
+	closed         bool
+	stop           chan struct{}
+	done           chan struct{}
 }
 
-func NewBot(log logger.Logger, cfg *config.AppConfig) *Bot {
+func NewBot(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore) *Bot {
 	return &Bot{
-		log: log.With().Str("module", "discord-bot").Logger(),
-		cfg: cfg,
+		log:        log.With().Str("module", "discord-bot").Logger(),
+		cfg:        cfg,
+		chapters:   chapters,
+		limiter:    NewRateLimiter(cfg.Config.DiscordRateLimit, time.Second),
+		lastChecks: make(map[string]time.Time),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
 
+// SetSession sets the Discord session without opening it, e.g. a session that sends to a fake Discord API.
+func (bot *Bot) SetSession(session *discordgo.Session) {
+	bot.discord = session
+}
+
 func (bot *Bot) Open() error {
 	var err error
 
@@ -28,37 +65,302 @@ func (bot *Bot) Open() error {
 
 	bot.discord, err = discordgo.New("Bot " + bot.cfg.Config.DiscordToken)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
 	}
 	bot.log.Info().Msg("Successfully logged in")
 
+	// discordgo waits for the Retry-After of rate limited requests before retrying them
+	bot.discord.ShouldRetryOnRateLimit = true
+	// discordgo would retry forever, onDisconnect reconnects with a limited number of attempts instead
+	bot.discord.ShouldReconnectOnError = false
+
+	bot.discord.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		utils.SafeGo(func() { bot.onInteractionCreate(s, i) }, bot.log, bot)
+	})
+	bot.discord.AddHandler(func(_ *discordgo.Session, _ *discordgo.Disconnect) {
+		bot.onDisconnect()
+	})
+	bot.discord.AddHandler(func(_ *discordgo.Session, _ *discordgo.Connect) {
+		utils.SafeGo(bot.onConnect, bot.log, bot)
+	})
+
 	bot.log.Debug().Msg("Creating websocket connection...")
 	err = bot.discord.Open()
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
 	}
 	bot.log.Debug().Msg("Successfully created websocket connection")
 
 	err = bot.discord.UpdateCustomStatus("Watching TCB Scans")
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
 	}
 	bot.log.Debug().Msg("Successfully updated custom status")
 
+	err = bot.RegisterCommands()
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
+	}
+	bot.log.Debug().Msg("Successfully registered slash commands")
+
+	bot.checkRolePermissions()
+
 	return nil
 }
 
-func (bot *Bot) SendDiscordNotification(title string, description string, url string, footer string, color int) {
-	_, err := bot.discord.ChannelMessageSendEmbed(bot.cfg.Config.DiscordChannelID, &discordgo.MessageEmbed{
-		Title:       title,
-		Description: description,
-		URL:         url,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: footer,
-		},
-		Color: color,
-	})
+// onConnect reports a lost session once the connection is back, nothing can be sent while it's gone.
+func (bot *Bot) onConnect() {
+	bot.m.Lock()
+	disconnectedAt := bot.disconnectedAt
+	bot.disconnectedAt = time.Time{}
+	bot.m.Unlock()
+
+	if disconnectedAt.IsZero() {
+		return
+	}
+
+	bot.log.Info().Msg("Reconnected to Discord")
+	bot.SendCriticalNotification(context.Background(), "Discord session lost", fmt.Sprintf(
+		"The connection to Discord was lost for %s, slash commands were unavailable in the meantime.",
+		utils.FormatDuration(time.Since(disconnectedAt))))
+}
+
+// Close closes the websocket connection to Discord and stops reconnecting. It gives up once ctx is done.
+func (bot *Bot) Close(ctx context.Context) error {
+	bot.m.Lock()
+	if !bot.closed {
+		bot.closed = true
+		close(bot.stop)
+	}
+	bot.m.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- bot.discord.Close() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsOpen reports whether the websocket connection to Discord is established.
+func (bot *Bot) IsOpen() bool {
+	return bot.discord != nil && bot.discord.DataReady
+}
+
+func (bot *Bot) SendNotification(ctx context.Context, notification Notification) (err error) {
+	ctx, span := otel.Tracer().Start(ctx, "Bot.SendNotification",
+		trace.WithAttributes(otel.ChapterAttributes(notification.MangaTitle, notification.ChapterNumber)...))
+	defer func() { otel.EndSpan(span, err) }()
+
+	// the retry queue sends the notification once the bot is connected again
+	if bot.isReconnecting() {
+		return errReconnecting
+	}
+
+	// webhooks can't send interactive components, so only the bot adds the buttons
+	message := newChapterMessage(bot.cfg, notification)
+	if !isPlain(bot.cfg) {
+		message.Components = chapterButtons(notification)
+	}
+
+	if len(bot.cfg.Config.Guilds) > 0 {
+		var errs []error
+		for _, channelID := range bot.guildChannels(notification.MangaTitle) {
+			errs = append(errs, bot.sendMessage(ctx, channelID, message))
+		}
+		err = errors.Join(errs...)
+	} else if forumChannelID := bot.cfg.Config.DiscordForumChannelID; forumChannelID != "" {
+		err = bot.sendToForum(ctx, forumChannelID, notification, message)
+	} else {
+		channelID := bot.cfg.Config.DiscordChannelID
+		if mangaChannelID, ok := utils.LookupTitle(bot.cfg.Config.MangaChannels, notification.MangaTitle); ok {
+			channelID = mangaChannelID
+		}
+		err = bot.sendMessage(ctx, channelID, message)
+	}
+	if err != nil {
+		metrics.DiscordError()
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
+	}
+
+	state.NotificationSent()
+	return nil
+}
+
+func (bot *Bot) SendWarnNotification(ctx context.Context, title string, description string) {
+	bot.send(ctx, bot.cfg.Config.DiscordWarnChannelID,
+		newEmbed(Notification{Title: title, Description: description, Color: warnColor}))
+}
+
+func (bot *Bot) SendErrorNotification(ctx context.Context, title string, description string, details string) {
+	bot.send(ctx, "", newEmbed(Notification{Title: title, Description: description, Color: errorColor,
+		Fields: DetailsFields(details), Author: errorAuthor()}))
+}
+
+func (bot *Bot) SendCriticalNotification(ctx context.Context, title string, description string) {
+	bot.send(ctx, bot.cfg.Config.DiscordCriticalChannelID,
+		newEmbed(Notification{Title: title, Description: description, Color: criticalColor,
+			Author: errorAuthor()}))
+}
+
+func (bot *Bot) SendResolvedNotification(ctx context.Context, title string, description string, details string) {
+	bot.send(ctx, "", newEmbed(Notification{Title: title, Description: description, Color: resolvedColor,
+		Fields: DetailsFields(details)}))
+}
+
+func (bot *Bot) SendHiatusNotification(ctx context.Context, title string, description string, color int) {
+	embed := newEmbed(Notification{Title: title, Description: description, Color: color})
+
+	switch {
+	case bot.cfg.Config.DiscordHiatusChannelID != "":
+		bot.sendTo(ctx, bot.cfg.Config.DiscordHiatusChannelID, embed)
+	case len(bot.cfg.Config.Guilds) > 0:
+		for _, channelID := range bot.allGuildChannels() {
+			bot.sendTo(ctx, channelID, embed)
+		}
+	default:
+		bot.sendTo(ctx, bot.cfg.Config.DiscordChannelID, embed)
+	}
+}
+
+// send sends the embed to the given channel. Without a channel, it's sent to the error channel, the error
+// channel of every guild or the notification channel if there are no guilds.
+func (bot *Bot) send(ctx context.Context, channelID string, embed *discordgo.MessageEmbed) {
+	if channelID == "" {
+		channelID = bot.cfg.Config.DiscordErrorChannelID
+	}
+	if channelID != "" {
+		bot.sendTo(ctx, channelID, embed)
+		return
+	}
+
+	if len(bot.cfg.Config.Guilds) > 0 {
+		for _, channelID := range bot.guildErrorChannels() {
+			bot.sendTo(ctx, channelID, embed)
+		}
+		return
+	}
+
+	bot.sendTo(ctx, bot.cfg.Config.DiscordChannelID, embed)
+}
+
+func (bot *Bot) sendTo(ctx context.Context, channelID string, embed *discordgo.MessageEmbed) {
+	if err := bot.limiter.Wait(ctx); err != nil {
+		bot.handleSendError(ctx, err)
+		return
+	}
+
+	var err error
+	if isPlain(bot.cfg) {
+		_, err = bot.discord.ChannelMessageSend(channelID, embedText(embed), discordgo.WithContext(ctx))
+	} else {
+		_, err = bot.discord.ChannelMessageSendEmbed(channelID, embed, discordgo.WithContext(ctx))
+	}
+	bot.handleSendError(ctx, err)
+}
+
+// sendMessage sends a chapter notification, errors are returned so the notification can be retried.
+func (bot *Bot) sendMessage(ctx context.Context, channelID string, message *discordgo.MessageSend) (err error) {
+	ctx, span := otel.Tracer().Start(ctx, "Bot.sendMessage", trace.WithAttributes(otel.DiscordChannelIDKey.String(channelID)))
+	defer func() { otel.EndSpan(span, err) }()
+
+	if err := bot.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	sent, err := bot.discord.ChannelMessageSendComplex(channelID, message, discordgo.WithContext(ctx))
 	if err != nil {
-		bot.log.Fatal().Err(err).Msg("Error sending Discord notification")
+		return err
+	}
+
+	bot.react(ctx, channelID, sent.ID)
+	return nil
+}
+
+// react adds discordReactEmoji to a sent chapter notification. Failing to react doesn't fail the notification.
+func (bot *Bot) react(ctx context.Context, channelID string, messageID string) {
+	emoji := bot.cfg.Config.DiscordReactEmoji
+	if !bot.cfg.Config.DiscordReactEmojiEnabled || emoji == "" {
+		return
+	}
+
+	if err := bot.limiter.Wait(ctx); err != nil {
+		bot.log.Warn().Err(err).Msgf("error reacting to notification with %q", emoji)
+		return
+	}
+
+	if err := bot.discord.MessageReactionAdd(channelID, messageID, emoji, discordgo.WithContext(ctx)); err != nil {
+		bot.log.Warn().Err(err).Msgf("error reacting to notification with %q", emoji)
+	}
+}
+
+// handleSendError logs errors sending notifications. They aren't retried, a failed notification must not crash the bot
+// that reports it.
+func (bot *Bot) handleSendError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	if ctx.Err() != nil {
+		bot.log.Warn().Err(err).Msg("Sending Discord notification was cancelled")
+		return
+	}
+
+	metrics.DiscordError()
+	bot.log.Error().Err(fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)).Msg("error sending Discord notification")
+}
+
+// checkRolePermissions warns about manga roles that can't be pinged, because the role isn't mentionable
+// and the bot isn't allowed to mention all roles.
+func (bot *Bot) checkRolePermissions() {
+	if len(bot.cfg.Config.MangaRoles) == 0 {
+		return
+	}
+
+	channelIDs := bot.allGuildChannels()
+	for _, channelID := range []string{bot.cfg.Config.DiscordChannelID, bot.cfg.Config.DiscordForumChannelID} {
+		if channelID != "" {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+
+	checkedGuilds := make(map[string]bool)
+	for _, channelID := range channelIDs {
+		channel, err := bot.discord.Channel(channelID)
+		if err != nil {
+			bot.log.Error().Err(err).Msgf("error fetching channel: %s", channelID)
+			continue
+		}
+		if checkedGuilds[channel.GuildID] {
+			continue
+		}
+		checkedGuilds[channel.GuildID] = true
+
+		permissions, err := bot.discord.UserChannelPermissions(bot.discord.State.User.ID, channelID)
+		if err != nil {
+			bot.log.Error().Err(err).Msgf("error fetching permissions for channel: %s", channelID)
+			continue
+		}
+		if permissions&discordgo.PermissionMentionEveryone != 0 {
+			continue
+		}
+
+		roles, err := bot.discord.GuildRoles(channel.GuildID)
+		if err != nil {
+			bot.log.Error().Err(err).Msgf("error fetching roles of guild: %s", channel.GuildID)
+			continue
+		}
+
+		for mangaTitle, roleID := range bot.cfg.Config.MangaRoles {
+			for _, role := range roles {
+				if role.ID == roleID && !role.Mentionable {
+					bot.log.Warn().Msgf("Role %q of %q can't be pinged, make it mentionable or allow the bot to "+
+						"mention all roles", role.Name, mangaTitle)
+				}
+			}
+		}
 	}
 }
@@ -0,0 +1,46 @@
+package discord
+
+import (
+	"context"
+	"tcb-bot/internal/logger"
+
+	"github.com/rs/zerolog"
+)
+
+// DryRunNotifier logs the notifications it receives instead of sending them to Discord.
+type DryRunNotifier struct {
+	log zerolog.Logger
+}
+
+func NewDryRunNotifier(log logger.Logger) *DryRunNotifier {
+	return &DryRunNotifier{
+		log: log.With().Str("module", "dry-run").Logger(),
+	}
+}
+
+func (n *DryRunNotifier) SendNotification(ctx context.Context, notification Notification) error {
+	n.log.Info().Msgf("Would send notification: %q %q %s", notification.Title, notification.Description,
+		notification.URL)
+	return nil
+}
+
+func (n *DryRunNotifier) SendWarnNotification(ctx context.Context, title string, description string) {
+	n.log.Info().Msgf("Would send warn notification: %q %q", title, description)
+}
+
+func (n *DryRunNotifier) SendErrorNotification(ctx context.Context, title string, description string, details string) {
+	n.log.Info().Msgf("Would send error notification: %q %q %q", title, description, details)
+}
+
+func (n *DryRunNotifier) SendCriticalNotification(ctx context.Context, title string, description string) {
+	n.log.Info().Msgf("Would send critical notification: %q %q", title, description)
+}
+
+func (n *DryRunNotifier) SendResolvedNotification(ctx context.Context, title string, description string,
+	details string) {
+	n.log.Info().Msgf("Would send resolved notification: %q %q %q", title, description, details)
+}
+
+func (n *DryRunNotifier) SendHiatusNotification(ctx context.Context, title string, description string, _ int) {
+	n.log.Info().Msgf("Would send hiatus notification: %q %q", title, description)
+}
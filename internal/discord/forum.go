@@ -0,0 +1,89 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tcb-bot/internal/otel"
+
+	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxForumTags and maxForumTagLength are limits of Discord forum channels
+	maxForumTags      = 20
+	maxForumTagLength = 20
+)
+
+// sendToForum creates a new thread for the chapter in the forum channel, tagged with the manga.
+func (bot *Bot) sendToForum(ctx context.Context, channelID string, notification Notification,
+	message *discordgo.MessageSend) (err error) {
+	ctx, span := otel.Tracer().Start(ctx, "Bot.sendToForum", trace.WithAttributes(otel.DiscordChannelIDKey.String(channelID)))
+	defer func() { otel.EndSpan(span, err) }()
+
+	thread := &discordgo.ThreadStart{
+		Name: fmt.Sprintf("%s Chapter %s", notification.MangaTitle, notification.ChapterNumber),
+	}
+	if tagID := bot.forumTag(ctx, channelID, notification.MangaTitle); tagID != "" {
+		thread.AppliedTags = []string{tagID}
+	}
+
+	if err := bot.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	started, err := bot.discord.ForumThreadStartComplex(channelID, thread, message, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	// the starter message of a forum thread has the ID of the thread
+	bot.react(ctx, started.ID, started.ID)
+	return nil
+}
+
+// forumTag returns the ID of the forum tag of the manga and creates the tag if it doesn't exist yet.
+// Returns an empty string if the tag couldn't be found or created.
+func (bot *Bot) forumTag(ctx context.Context, channelID string, mangaTitle string) string {
+	name := mangaTitle
+	if runes := []rune(name); len(runes) > maxForumTagLength {
+		name = string(runes[:maxForumTagLength])
+	}
+
+	channel, err := bot.discord.Channel(channelID, discordgo.WithContext(ctx))
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error fetching forum channel")
+		return ""
+	}
+
+	for _, tag := range channel.AvailableTags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag.ID
+		}
+	}
+
+	if len(channel.AvailableTags) >= maxForumTags {
+		bot.log.Warn().Msgf("Forum channel already has %d tags, not tagging thread: %q", maxForumTags, mangaTitle)
+		return ""
+	}
+
+	tags := append(channel.AvailableTags, discordgo.ForumTag{Name: name})
+	channel, err = bot.discord.ChannelEditComplex(channelID, &discordgo.ChannelEdit{
+		AvailableTags: &tags,
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		bot.log.Error().Err(err).Msgf("error creating forum tag: %q", name)
+		return ""
+	}
+
+	for _, tag := range channel.AvailableTags {
+		if tag.Name == name {
+			bot.log.Debug().Msgf("Created forum tag: %q", name)
+			return tag.ID
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,45 @@
+package discord
+
+import (
+	"slices"
+
+	"tcb-bot/internal/utils"
+)
+
+// guildChannels returns the channels of all guilds that watch the manga.
+func (bot *Bot) guildChannels(mangaTitle string) []string {
+	var channelIDs []string
+	for _, guild := range bot.cfg.Config.Guilds {
+		if bot.cfg.Config.WatchAll || slices.ContainsFunc(guild.WatchedMangas, func(watched string) bool {
+			return utils.TitleMatches(mangaTitle, watched, bot.cfg.Config.FuzzyMatch)
+		}) {
+			channelIDs = append(channelIDs, guild.DiscordChannelID)
+		}
+	}
+
+	return channelIDs
+}
+
+// guildErrorChannels returns the error channel of every guild, falling back to its channel.
+func (bot *Bot) guildErrorChannels() []string {
+	var channelIDs []string
+	for _, guild := range bot.cfg.Config.Guilds {
+		if guild.DiscordErrorChannelID != "" {
+			channelIDs = append(channelIDs, guild.DiscordErrorChannelID)
+		} else {
+			channelIDs = append(channelIDs, guild.DiscordChannelID)
+		}
+	}
+
+	return channelIDs
+}
+
+// allGuildChannels returns the channel of every guild.
+func (bot *Bot) allGuildChannels() []string {
+	var channelIDs []string
+	for _, guild := range bot.cfg.Config.Guilds {
+		channelIDs = append(channelIDs, guild.DiscordChannelID)
+	}
+
+	return channelIDs
+}
@@ -0,0 +1,157 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tcb-bot/internal/domain"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	historyPageSize     = 10
+	historyDefaultLimit = 10
+	historyMaxLimit     = 50
+
+	// historyButtonPrefix starts the custom ID of the navigation buttons, followed by page, limit and manga
+	historyButtonPrefix = "history:"
+)
+
+// HistoryFunc returns up to limit chapters of the manga, starting with the latest.
+type HistoryFunc func(ctx context.Context, mangaTitle string, limit int) ([]domain.ChapterInfo, error)
+
+// SetHistoryFunc sets the function that is used by the /history command.
+func (bot *Bot) SetHistoryFunc(fn HistoryFunc) {
+	bot.historyFunc = fn
+}
+
+func (bot *Bot) handleHistory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if bot.historyFunc == nil {
+		bot.respondEphemeral(s, i, "The history isn't available right now.")
+		return
+	}
+
+	var mangaTitle string
+	limit := historyDefaultLimit
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "manga":
+			mangaTitle = strings.TrimSpace(option.StringValue())
+		case "limit":
+			limit = int(option.IntValue())
+		}
+	}
+
+	response, err := bot.historyResponse(mangaTitle, limit, 0)
+	if err != nil {
+		bot.log.Error().Err(err).Msgf("error loading history: %q", mangaTitle)
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error loading history: %v", err))
+		return
+	}
+	if response == nil {
+		bot.respondEphemeral(s, i, fmt.Sprintf("No chapters found for %s.", mangaTitle))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: response,
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
+
+// handleHistoryButton shows another page of the history when a navigation button is pressed.
+func (bot *Bot) handleHistoryButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.SplitN(strings.TrimPrefix(i.MessageComponentData().CustomID, historyButtonPrefix), ":", 3)
+	if len(parts) != 3 || bot.historyFunc == nil {
+		return
+	}
+
+	page, _ := strconv.Atoi(parts[0])
+	limit, _ := strconv.Atoi(parts[1])
+	mangaTitle := parts[2]
+
+	response, err := bot.historyResponse(mangaTitle, limit, page)
+	if err != nil || response == nil {
+		bot.log.Error().Err(err).Msgf("error loading history: %q", mangaTitle)
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: response,
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
+
+// historyResponse builds the given page of the history, nil is returned if there are no chapters.
+func (bot *Bot) historyResponse(mangaTitle string, limit int, page int) (*discordgo.InteractionResponseData, error) {
+	limit = min(max(limit, 1), historyMaxLimit)
+
+	chapters, err := bot.historyFunc(context.Background(), mangaTitle, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+
+	pages := (len(chapters) + historyPageSize - 1) / historyPageSize
+	page = min(max(page, 0), pages-1)
+
+	var lines []string
+	for _, chapter := range chapters[page*historyPageSize : min((page+1)*historyPageSize, len(chapters))] {
+		line := fmt.Sprintf("**Chapter %s**", chapter.ChapterNumber)
+		if chapter.ChapterTitle != "" {
+			line += ": " + chapter.ChapterTitle
+		}
+		lines = append(lines, line+"\n"+chapter.FormattedTime(bot.cfg.Config.DisplayTimezone))
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       fmt.Sprintf("History of %s", chapters[0].MangaTitle),
+				Description: strings.Join(lines, "\n\n"),
+				Color:       3447003,
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: fmt.Sprintf("Page %d of %d", page+1, pages),
+				},
+			},
+		},
+	}
+
+	if pages > 1 {
+		buttonID := func(page int) string {
+			return fmt.Sprintf("%s%d:%d:%s", historyButtonPrefix, page, limit, mangaTitle)
+		}
+
+		data.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Previous",
+						Style:    discordgo.SecondaryButton,
+						CustomID: buttonID(page - 1),
+						Disabled: page == 0,
+					},
+					discordgo.Button{
+						Label:    "Next",
+						Style:    discordgo.SecondaryButton,
+						CustomID: buttonID(page + 1),
+						Disabled: page == pages-1,
+					},
+				},
+			},
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,283 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	warnColor     = 16776960
+	errorColor    = 15105570
+	criticalColor = 15158332
+	resolvedColor = 3066993
+
+	// maxFieldValueLength is the limit of the value of an embed field
+	maxFieldValueLength = 1024
+
+	// repositoryURL is linked by the author of error notifications
+	repositoryURL = "https://github.com/nuxencs/tcb-bot"
+)
+
+// roleMentionRegex matches role mentions like <@&123456789012345678>, the role ID is the first group
+var roleMentionRegex = regexp.MustCompile(`<@&(\d+)>`)
+
+// Notifier is implemented by everything that can deliver notifications, e.g. to Discord or Telegram.
+type Notifier interface {
+	// SendNotification sends a chapter notification, errors are returned so it can be retried later
+	SendNotification(ctx context.Context, notification Notification) error
+	SendWarnNotification(ctx context.Context, title string, description string)
+	SendErrorNotification(ctx context.Context, title string, description string, details string)
+	SendCriticalNotification(ctx context.Context, title string, description string)
+	SendResolvedNotification(ctx context.Context, title string, description string, details string)
+	SendHiatusNotification(ctx context.Context, title string, description string, color int)
+}
+
+// Notification holds everything that's shown in a chapter notification.
+type Notification struct {
+	MangaTitle    string
+	ChapterNumber string
+	ChapterTitle  string
+	// ReleaseTime is formatted for display, it's only set for chapter notifications
+	ReleaseTime string
+	Title       string
+	Description string
+	URL         string
+	Footer      string
+	// FooterIconURL is only set for chapter notifications
+	FooterIconURL string
+	// Author is only set for error notifications and chapter notifications if discordEmbedAuthorName is set
+	Author       *discordgo.MessageEmbedAuthor
+	Color        int
+	ThumbnailURL string
+	ImageURL     string
+	Fields       []*discordgo.MessageEmbedField
+	// Silent suppresses the ping of the manga role, e.g. during its ping cooldown
+	Silent bool
+	// Notifiers limits a MultiNotifier to the notifiers with these names, e.g. when a notification is retried only for
+	// the notifiers that failed to send it. It's sent by all of them if it's empty.
+	Notifiers []string
+}
+
+// newEmbed builds the embed used by every Notifier, so all of them produce the same format.
+func newEmbed(notification Notification) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       notification.Title,
+		Description: notification.Description,
+		URL:         notification.URL,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    notification.Footer,
+			IconURL: notification.FooterIconURL,
+		},
+		Color:  notification.Color,
+		Fields: notification.Fields,
+		Author: notification.Author,
+	}
+
+	if notification.ThumbnailURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: notification.ThumbnailURL}
+	}
+	if notification.ImageURL != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: notification.ImageURL}
+	}
+
+	return embed
+}
+
+// errorAuthor returns the author of error notifications, it links the repository of tcb-bot.
+func errorAuthor() *discordgo.MessageEmbedAuthor {
+	return &discordgo.MessageEmbedAuthor{Name: "tcb-bot", URL: repositoryURL}
+}
+
+// ChapterAuthor returns the configured author of chapter notifications, nil if discordEmbedAuthorName isn't set.
+func ChapterAuthor(cfg *config.AppConfig) *discordgo.MessageEmbedAuthor {
+	if cfg.Config.DiscordEmbedAuthorName == "" {
+		return nil
+	}
+
+	return &discordgo.MessageEmbedAuthor{
+		Name:    cfg.Config.DiscordEmbedAuthorName,
+		URL:     cfg.Config.DiscordEmbedAuthorURL,
+		IconURL: cfg.Config.DiscordEmbedAuthorIconURL,
+	}
+}
+
+// DetailsFields returns the details of an error or resolved notification as a single field, nil if there are none.
+func DetailsFields(details string) []*discordgo.MessageEmbedField {
+	if details == "" {
+		return nil
+	}
+
+	if runes := []rune(details); len(runes) > maxFieldValueLength {
+		details = string(runes[:maxFieldValueLength-1]) + "…"
+	}
+
+	return []*discordgo.MessageEmbedField{{Name: "Details", Value: details}}
+}
+
+// isPlain reports whether notifications are sent as plain text messages instead of embeds.
+func isPlain(cfg *config.AppConfig) bool {
+	return cfg.Config.NotificationStyle == domain.NotificationStylePlain
+}
+
+// chapterText formats a chapter notification as plain text.
+func chapterText(notification Notification) string {
+	text := fmt.Sprintf("**%s** Chapter %s", notification.MangaTitle, notification.ChapterNumber)
+	if notification.ChapterTitle != "" {
+		text += ": " + notification.ChapterTitle
+	}
+	if notification.URL != "" {
+		text += "\n" + notification.URL
+	}
+	if notification.ReleaseTime != "" {
+		text += "\nReleased at " + notification.ReleaseTime
+	}
+
+	return text
+}
+
+// embedText formats the embed of a notification that isn't a chapter notification as plain text.
+func embedText(embed *discordgo.MessageEmbed) string {
+	lines := []string{"**" + embed.Title + "**"}
+	if embed.Description != "" {
+		lines = append(lines, embed.Description)
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, fmt.Sprintf("**%s:** %s", field.Name, field.Value))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// newChapterMessage builds the message of a chapter notification. If a role is configured for the manga, it's pinged
+// in the message content unless the notification is silent. Only that role may be mentioned, @everyone and @here are
+// never pinged. In the plain notificationStyle, the chapter is part of the content instead of an embed.
+func newChapterMessage(cfg *config.AppConfig, notification Notification) *discordgo.MessageSend {
+	message := &discordgo.MessageSend{
+		AllowedMentions: &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}},
+	}
+	if !isPlain(cfg) {
+		message.Embeds = []*discordgo.MessageEmbed{newEmbed(notification)}
+	}
+
+	var content []string
+	if prefix, ok := utils.LookupTitle(cfg.Config.MangaMessagePrefix, notification.MangaTitle); ok && prefix != "" {
+		content = append(content, prefix)
+	}
+	if roleID, ok := utils.LookupTitle(cfg.Config.MangaRoles, notification.MangaTitle); ok && !notification.Silent {
+		content = append(content, fmt.Sprintf("<@&%s>", roleID))
+	}
+	if suffix, ok := utils.LookupTitle(cfg.Config.MangaMessageSuffix, notification.MangaTitle); ok && suffix != "" {
+		content = append(content, suffix)
+	}
+	message.Content = strings.Join(content, " ")
+	if isPlain(cfg) {
+		message.Content = strings.TrimPrefix(message.Content+"\n"+chapterText(notification), "\n")
+	}
+
+	// only the roles mentioned in the content are pinged, none of them during the ping cooldown
+	if !notification.Silent {
+		for _, match := range roleMentionRegex.FindAllStringSubmatch(message.Content, -1) {
+			if !slices.Contains(message.AllowedMentions.Roles, match[1]) {
+				message.AllowedMentions.Roles = append(message.AllowedMentions.Roles, match[1])
+			}
+		}
+	}
+
+	return message
+}
+
+// NamedNotifier is a notifier of a MultiNotifier, its name identifies it when a notification is retried.
+type NamedNotifier struct {
+	Name string
+	Notifier
+}
+
+// MultiNotifier sends every notification to all of its notifiers, e.g. to Discord and Telegram.
+type MultiNotifier []NamedNotifier
+
+// MultiSendError is returned by MultiNotifier.SendNotification if any of its notifiers failed, only those have to send
+// the notification again.
+type MultiSendError struct {
+	// Failed holds the names of the notifiers that failed
+	Failed []string
+	Err    error
+}
+
+func (e *MultiSendError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MultiSendError) Unwrap() error {
+	return e.Err
+}
+
+// FailedNotifiers returns the names of the notifiers of a MultiNotifier that failed to send a notification, nil if
+// err isn't a MultiSendError.
+func FailedNotifiers(err error) []string {
+	var multiErr *MultiSendError
+	if errors.As(err, &multiErr) {
+		return multiErr.Failed
+	}
+
+	return nil
+}
+
+func (m MultiNotifier) SendNotification(ctx context.Context, notification Notification) error {
+	var failed []string
+	var errs []error
+	for _, notifier := range m {
+		if len(notification.Notifiers) > 0 && !slices.Contains(notification.Notifiers, notifier.Name) {
+			continue
+		}
+
+		if err := notifier.SendNotification(ctx, notification); err != nil {
+			failed = append(failed, notifier.Name)
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &MultiSendError{Failed: failed, Err: errors.Join(errs...)}
+}
+
+func (m MultiNotifier) SendWarnNotification(ctx context.Context, title string, description string) {
+	for _, notifier := range m {
+		notifier.SendWarnNotification(ctx, title, description)
+	}
+}
+
+func (m MultiNotifier) SendErrorNotification(ctx context.Context, title string, description string, details string) {
+	for _, notifier := range m {
+		notifier.SendErrorNotification(ctx, title, description, details)
+	}
+}
+
+func (m MultiNotifier) SendCriticalNotification(ctx context.Context, title string, description string) {
+	for _, notifier := range m {
+		notifier.SendCriticalNotification(ctx, title, description)
+	}
+}
+
+func (m MultiNotifier) SendResolvedNotification(ctx context.Context, title string, description string, details string) {
+	for _, notifier := range m {
+		notifier.SendResolvedNotification(ctx, title, description, details)
+	}
+}
+
+func (m MultiNotifier) SendHiatusNotification(ctx context.Context, title string, description string, color int) {
+	for _, notifier := range m {
+		notifier.SendHiatusNotification(ctx, title, description, color)
+	}
+}
@@ -0,0 +1,60 @@
+package discord
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// pingSlowAfter and pingCriticalAfter are the response times after which /ping turns yellow and red
+	pingSlowAfter     = 3 * time.Second
+	pingCriticalAfter = 5 * time.Second
+)
+
+// handlePing answers /ping with the response time of the bot. It's measured from the creation of the interaction,
+// which is encoded in its ID, so delays of Discord delivering it are included.
+func (bot *Bot) handlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	received, err := discordgo.SnowflakeTimestamp(i.ID)
+	if err != nil {
+		received = time.Now()
+	}
+	responseTime := max(time.Since(received), 0)
+
+	color := healthyColor
+	switch {
+	case responseTime > pingCriticalAfter:
+		color = criticalColor
+	case responseTime > pingSlowAfter:
+		color = degradedColor
+	}
+
+	username := "Unknown"
+	if s.State != nil && s.State.User != nil {
+		username = s.State.User.Username
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Pong!",
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Bot", Value: username, Inline: true},
+			{Name: "Response time", Value: fmt.Sprintf("%d ms", responseTime.Milliseconds()), Inline: true},
+			{Name: "API latency", Value: fmt.Sprintf("%d ms", s.HeartbeatLatency().Milliseconds()), Inline: true},
+			{Name: "Go version", Value: runtime.Version(), Inline: true},
+		},
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// HeldError is returned for chapter notifications that are held back during quiet hours. They aren't sent, the
+// caller is responsible for sending them again once the quiet hours end.
+type HeldError struct {
+	Until time.Time
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("quiet hours are active until %s", e.Until.Format(time.RFC1123))
+}
+
+// NotificationQueue holds back chapter notifications during the configured quiet hours by returning a HeldError,
+// the collector queues them until the quiet hours end. Error and resolved notifications are never held back.
+type NotificationQueue struct {
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	notifier Notifier
+}
+
+func NewNotificationQueue(log logger.Logger, cfg *config.AppConfig, notifier Notifier) *NotificationQueue {
+	return &NotificationQueue{
+		log:      log.With().Str("module", "notification-queue").Logger(),
+		cfg:      cfg,
+		notifier: notifier,
+	}
+}
+
+func (q *NotificationQueue) SendNotification(ctx context.Context, notification Notification) error {
+	end, quiet, err := utils.QuietHoursEnd(time.Now(), q.cfg.Config.QuietHoursStart, q.cfg.Config.QuietHoursEnd,
+		q.cfg.Config.QuietHoursTZ)
+	if err != nil {
+		q.log.Error().Err(err).Msg("error checking quiet hours, sending notification right away")
+	}
+
+	if !quiet {
+		return q.notifier.SendNotification(ctx, notification)
+	}
+
+	return &HeldError{Until: end}
+}
+
+func (q *NotificationQueue) SendWarnNotification(ctx context.Context, title string, description string) {
+	q.notifier.SendWarnNotification(ctx, title, description)
+}
+
+func (q *NotificationQueue) SendErrorNotification(ctx context.Context, title string, description string,
+	details string) {
+	q.notifier.SendErrorNotification(ctx, title, description, details)
+}
+
+func (q *NotificationQueue) SendCriticalNotification(ctx context.Context, title string, description string) {
+	q.notifier.SendCriticalNotification(ctx, title, description)
+}
+
+func (q *NotificationQueue) SendResolvedNotification(ctx context.Context, title string, description string,
+	details string) {
+	q.notifier.SendResolvedNotification(ctx, title, description, details)
+}
+
+func (q *NotificationQueue) SendHiatusNotification(ctx context.Context, title string, description string, color int) {
+	q.notifier.SendHiatusNotification(ctx, title, description, color)
+}
@@ -0,0 +1,61 @@
+package discord
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that allows up to limit messages per interval. Discord enforces its own
+// limits as well, but hitting them slows down every following request.
+type RateLimiter struct {
+	m      sync.Mutex
+	burst  float64
+	rate   float64 // tokens per second
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(limit int, interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		burst:  float64(limit),
+		rate:   float64(limit) / interval.Seconds(),
+		tokens: float64(limit),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a message may be sent or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise it returns how long it takes until the next one is.
+func (l *RateLimiter) reserve() time.Duration {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,93 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// readButtonPrefix starts the custom ID of the "Mark as Read" button, followed by the release title
+	readButtonPrefix = "read:"
+
+	// maxCustomIDLength is the limit of Discord for custom IDs of components
+	maxCustomIDLength = 100
+)
+
+// MarkReadFunc marks the chapter with the release title as read.
+type MarkReadFunc func(ctx context.Context, releaseTitle string, readAt time.Time) error
+
+// SetMarkReadFunc sets the function that is used by the "Mark as Read" button.
+func (bot *Bot) SetMarkReadFunc(fn MarkReadFunc) {
+	bot.markReadFunc = fn
+}
+
+// chapterButtons returns the "Mark as Read" and "Open Chapter" buttons of a chapter notification.
+func chapterButtons(notification Notification) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+
+	customID := readButtonPrefix + fmt.Sprintf("%s Chapter %s", notification.MangaTitle, notification.ChapterNumber)
+	if len(customID) <= maxCustomIDLength {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Mark as Read",
+			Style:    discordgo.SuccessButton,
+			CustomID: customID,
+		})
+	}
+
+	if notification.URL != "" {
+		buttons = append(buttons, discordgo.Button{
+			Label: "Open Chapter",
+			Style: discordgo.LinkButton,
+			URL:   notification.URL,
+		})
+	}
+
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+func (bot *Bot) handleReadButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if bot.markReadFunc == nil {
+		bot.respondEphemeral(s, i, "Marking chapters as read isn't available right now.")
+		return
+	}
+
+	releaseTitle := strings.TrimPrefix(i.MessageComponentData().CustomID, readButtonPrefix)
+	readAt := time.Now()
+	if err := bot.markReadFunc(context.Background(), releaseTitle, readAt); err != nil {
+		bot.log.Error().Err(err).Msgf("error marking chapter as read: %q", releaseTitle)
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error marking chapter as read: %v", err))
+		return
+	}
+	bot.log.Debug().Msgf("Marked chapter as read: %q", releaseTitle)
+
+	bot.respondEphemeral(s, i, "Marked as read!")
+
+	if i.Message == nil || len(i.Message.Embeds) == 0 {
+		return
+	}
+
+	embed := i.Message.Embeds[0]
+	if embed.Footer == nil {
+		embed.Footer = &discordgo.MessageEmbedFooter{}
+	}
+	if !strings.Contains(embed.Footer.Text, "Read at") {
+		embed.Footer.Text += " • Read at " + readAt.Format(time.RFC1123)
+	}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      i.Message.ID,
+		Channel: i.Message.ChannelID,
+		Embeds:  &[]*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msgf("error updating footer of chapter: %q", releaseTitle)
+	}
+}
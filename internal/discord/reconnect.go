@@ -0,0 +1,99 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxReconnectAttempts is the number of failed reconnects after which the bot gives up and shuts down
+	maxReconnectAttempts = 10
+
+	// minReconnectBackoff and maxReconnectBackoff bound the exponential back-off between two reconnects
+	minReconnectBackoff = 5 * time.Second
+	maxReconnectBackoff = 5 * time.Minute
+)
+
+// errReconnecting is returned for chapter notifications sent while the session reconnects, so they're retried.
+var errReconnecting = fmt.Errorf("%w: reconnecting to Discord", domain.ErrDiscordSendFailure)
+
+// reconnectBackoff returns how long to wait before the given reconnect attempt, starting at minReconnectBackoff and
+// doubling with every attempt.
+func reconnectBackoff(attempt int) time.Duration {
+	backoff := minReconnectBackoff << attempt
+	if backoff <= 0 || backoff > maxReconnectBackoff {
+		return maxReconnectBackoff
+	}
+
+	return backoff
+}
+
+// Done is closed once the bot gave up reconnecting to Discord, tcb-bot has to shut down then.
+func (bot *Bot) Done() <-chan struct{} {
+	return bot.done
+}
+
+// isReconnecting reports whether the websocket connection was lost and the bot is reconnecting.
+func (bot *Bot) isReconnecting() bool {
+	bot.m.Lock()
+	defer bot.m.Unlock()
+
+	return bot.reconnecting
+}
+
+// onDisconnect starts reconnecting, unless the bot is already reconnecting or the session was closed by Close.
+func (bot *Bot) onDisconnect() {
+	bot.m.Lock()
+	defer bot.m.Unlock()
+
+	if bot.closed || bot.reconnecting {
+		return
+	}
+
+	bot.log.Warn().Msg("Lost connection to Discord, reconnecting...")
+	bot.reconnecting = true
+	if bot.disconnectedAt.IsZero() {
+		bot.disconnectedAt = time.Now()
+	}
+
+	go utils.SafeGo(bot.reconnect, bot.log, bot)
+}
+
+// reconnect opens the session again with an exponential back-off. After maxReconnectAttempts failed attempts,
+// Done is closed.
+func (bot *Bot) reconnect() {
+	for attempt := 0; attempt < maxReconnectAttempts; attempt++ {
+		backoff := reconnectBackoff(attempt)
+		bot.log.Warn().Msgf("Reconnecting to Discord in %s, attempt %d of %d", backoff, attempt+1,
+			maxReconnectAttempts)
+
+		select {
+		case <-bot.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		err := bot.discord.Open()
+		if err == nil || errors.Is(err, discordgo.ErrWSAlreadyOpen) {
+			bot.m.Lock()
+			bot.reconnecting = false
+			bot.m.Unlock()
+			return
+		}
+
+		bot.log.Warn().Err(err).Msgf("error reconnecting to Discord, attempt %d of %d", attempt+1,
+			maxReconnectAttempts)
+	}
+
+	// logged without exiting, so main can shut down gracefully and save the collected chapters
+	bot.log.WithLevel(zerolog.FatalLevel).Msgf("Could not reconnect to Discord after %d attempts, shutting down",
+		maxReconnectAttempts)
+	close(bot.done)
+}
@@ -0,0 +1,74 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// searchMaxResults is the number of chapters shown by /search
+const searchMaxResults = 10
+
+// SearchFunc returns up to limit chapters whose release title or chapter title matches query, best matches first.
+type SearchFunc func(ctx context.Context, query string, limit int) ([]domain.CollectedChapter, error)
+
+// SetSearchFunc sets the function that is used by the /search command.
+func (bot *Bot) SetSearchFunc(fn SearchFunc) {
+	bot.searchFunc = fn
+}
+
+func (bot *Bot) handleSearch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if bot.searchFunc == nil {
+		bot.respondEphemeral(s, i, "Searching isn't available right now.")
+		return
+	}
+
+	var query string
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "query" {
+			query = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	chapters, err := bot.searchFunc(context.Background(), query, searchMaxResults)
+	if err != nil {
+		bot.log.Error().Err(err).Msgf("error searching chapters: %q", query)
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error searching chapters: %v", err))
+		return
+	}
+	if len(chapters) == 0 {
+		bot.respondEphemeral(s, i, fmt.Sprintf("No chapters found for %s.", query))
+		return
+	}
+
+	var lines []string
+	for _, chapter := range chapters {
+		line := fmt.Sprintf("[%s](%s)", chapter.ReleaseTitle,
+			utils.ReleaseURL(bot.cfg.Config.ScrapeURLs[0], chapter.ReleaseLink))
+		if chapter.ChapterTitle != "" {
+			line += "\n" + chapter.ChapterTitle
+		}
+		lines = append(lines, line)
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       fmt.Sprintf("Search results for %s", query),
+					Description: strings.Join(lines, "\n\n"),
+					Color:       3447003,
+				},
+			},
+		},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
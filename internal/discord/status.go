@@ -0,0 +1,115 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/state"
+	"tcb-bot/internal/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	healthyColor  = 3066993
+	degradedColor = 16776960
+
+	// statusTopMangas is the number of most active mangas listed by /status
+	statusTopMangas = 5
+
+	// statusBannerURL is the TCB Scans logo shown at the bottom of /status
+	statusBannerURL = "https://tcbscans.me/files/tcb-scans-logo.png"
+)
+
+// StatsFunc returns the chapter statistics of every manga, sorted by chapter count descending.
+type StatsFunc func(ctx context.Context) ([]domain.MangaStat, error)
+
+// SetStatsFunc sets the function that is used by the /status command.
+func (bot *Bot) SetStatsFunc(fn StatsFunc) {
+	bot.statsFunc = fn
+}
+
+func (bot *Bot) handleStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	uptime := time.Since(state.StartTime())
+	staleAfter := 2 * time.Duration(bot.cfg.Config.SleepTimer) * time.Minute
+
+	lastScrape := "Never"
+	color := healthyColor
+	if lastScrapeTime := state.LastScrapeTime(); !lastScrapeTime.IsZero() {
+		lastScrape = fmt.Sprintf("<t:%d:R>", lastScrapeTime.Unix())
+		if time.Since(lastScrapeTime) > staleAfter {
+			color = degradedColor
+		}
+	} else if uptime > staleAfter {
+		color = degradedColor
+	}
+
+	circuit := state.CircuitState()
+	if circuit != "closed" {
+		color = degradedColor
+	}
+
+	title := "Status"
+	if bot.cfg.Config.DryRun {
+		title += " (dry run)"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Uptime", Value: utils.FormatDuration(uptime), Inline: true},
+			{Name: "Last successful check", Value: lastScrape, Inline: true},
+			{Name: "Chapters collected", Value: strconv.Itoa(domain.CountChapters(bot.chapters)), Inline: true},
+			{Name: "Notifications sent", Value: strconv.FormatInt(state.TotalNotificationsSent(), 10), Inline: true},
+			{Name: "Latency", Value: s.HeartbeatLatency().Round(time.Millisecond).String(), Inline: true},
+			{Name: "Log level", Value: bot.cfg.Config.LogLevel, Inline: true},
+			{Name: "Circuit", Value: circuit, Inline: true},
+			{Name: "Dry run", Value: strconv.FormatBool(bot.cfg.Config.DryRun), Inline: true},
+		},
+		Image: &discordgo.MessageEmbedImage{URL: statusBannerURL},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Version " + bot.cfg.Config.Version,
+		},
+	}
+
+	if topMangas := bot.topMangas(); topMangas != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Most active mangas", Value: topMangas})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
+
+// topMangas lists the mangas with the most collected chapters, one per line.
+func (bot *Bot) topMangas() string {
+	if bot.statsFunc == nil {
+		return ""
+	}
+
+	stats, err := bot.statsFunc(context.Background())
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error loading manga stats")
+		return ""
+	}
+
+	var lines []string
+	for i, stat := range stats[:min(len(stats), statusTopMangas)] {
+		lines = append(lines, fmt.Sprintf("%d. %s: %d chapters, latest %s", i+1, stat.MangaTitle, stat.ChapterCount,
+			stat.LatestChapterNumber))
+	}
+
+	return strings.Join(lines, "\n")
+}
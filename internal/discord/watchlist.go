@@ -0,0 +1,153 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxWatchlistSize is the maximum size of an imported watchlist file in bytes.
+const maxWatchlistSize = 1 << 20
+
+func (bot *Bot) handleWatchlist(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+
+	switch options[0].Name {
+	case "export":
+		bot.handleWatchlistExport(s, i)
+	case "import":
+		bot.handleWatchlistImport(s, i, options[0].Options)
+	}
+}
+
+func (bot *Bot) handleWatchlistExport(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data, err := utils.MarshalWatchlist(domain.Watchlist{
+		WatchedMangas: bot.cfg.Config.WatchedMangas,
+		MangaChannels: bot.cfg.Config.MangaChannels,
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error exporting watchlist")
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error exporting watchlist: %v", err))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Watching %d mangas.", len(bot.cfg.Config.WatchedMangas)),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{
+				{
+					Name:        "watchlist.json",
+					ContentType: "application/json",
+					Reader:      bytes.NewReader(data),
+				},
+			},
+		},
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error responding to interaction")
+	}
+}
+
+func (bot *Bot) handleWatchlistImport(s *discordgo.Session, i *discordgo.InteractionCreate,
+	options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var attachmentID string
+	var replace bool
+	for _, option := range options {
+		switch option.Name {
+		case "file":
+			attachmentID, _ = option.Value.(string)
+		case "replace":
+			replace = option.BoolValue()
+		}
+	}
+
+	attachment, ok := i.ApplicationCommandData().Resolved.Attachments[attachmentID]
+	if !ok {
+		bot.respondEphemeral(s, i, "Please attach a watchlist file.")
+		return
+	}
+
+	imported, err := downloadWatchlist(attachment.URL)
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error importing watchlist")
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error importing watchlist: %v", err))
+		return
+	}
+
+	var watchedMangas []string
+	err = bot.cfg.UpdateWatchlist(func(watchlist domain.Watchlist) domain.Watchlist {
+		if replace {
+			watchedMangas = imported.WatchedMangas
+			return imported
+		}
+
+		watchedMangas = mergeWatchedMangas(watchlist.WatchedMangas, imported.WatchedMangas)
+		if watchlist.MangaChannels == nil {
+			watchlist.MangaChannels = make(map[string]string)
+		}
+		maps.Copy(watchlist.MangaChannels, imported.MangaChannels)
+
+		return domain.Watchlist{WatchedMangas: watchedMangas, MangaChannels: watchlist.MangaChannels}
+	})
+	if err != nil {
+		bot.log.Error().Err(err).Msg("error saving imported watchlist")
+		bot.respondEphemeral(s, i, fmt.Sprintf("Error saving watchlist: %v", err))
+		return
+	}
+
+	bot.log.Info().Msgf("Imported watchlist with %d mangas, now watching %d mangas", len(imported.WatchedMangas),
+		len(watchedMangas))
+	bot.respondEphemeral(s, i, fmt.Sprintf("Imported %d mangas, now watching %d mangas.",
+		len(imported.WatchedMangas), len(watchedMangas)))
+}
+
+func downloadWatchlist(url string) (domain.Watchlist, error) {
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return domain.Watchlist{}, errors.Wrap(err, "could not download watchlist")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.Watchlist{}, errors.New("could not download watchlist, status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWatchlistSize))
+	if err != nil {
+		return domain.Watchlist{}, errors.Wrap(err, "could not read watchlist")
+	}
+
+	return utils.UnmarshalWatchlist(data)
+}
+
+// mergeWatchedMangas adds all imported mangas that aren't watched yet.
+func mergeWatchedMangas(watched, imported []string) []string {
+	merged := slices.Clone(watched)
+	for _, manga := range imported {
+		if !slices.ContainsFunc(merged, func(m string) bool { return strings.EqualFold(m, manga) }) {
+			merged = append(merged, manga)
+		}
+	}
+
+	return merged
+}
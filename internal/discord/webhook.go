@@ -0,0 +1,145 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/metrics"
+	"tcb-bot/internal/otel"
+	"tcb-bot/internal/state"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WebhookNotifier sends notifications using a Discord webhook, no bot account is required.
+type WebhookNotifier struct {
+	log       zerolog.Logger
+	cfg       *config.AppConfig
+	discord   *discordgo.Session
+	limiter   *RateLimiter
+	webhookID string
+	token     string
+}
+
+func NewWebhookNotifier(log logger.Logger, cfg *config.AppConfig) (*WebhookNotifier, error) {
+	webhookID, token, err := parseWebhookURL(cfg.Config.DiscordWebhookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// executing a webhook is authenticated by its token, the session doesn't need one
+	session, err := discordgo.New("")
+	if err != nil {
+		return nil, err
+	}
+
+	// discordgo waits for the Retry-After of rate limited requests before retrying them
+	session.ShouldRetryOnRateLimit = true
+
+	return &WebhookNotifier{
+		log:       log.With().Str("module", "discord-webhook").Logger(),
+		cfg:       cfg,
+		discord:   session,
+		limiter:   NewRateLimiter(cfg.Config.DiscordRateLimit, time.Second),
+		webhookID: webhookID,
+		token:     token,
+	}, nil
+}
+
+// parseWebhookURL extracts the id and token from a webhook url like
+// https://discord.com/api/webhooks/<id>/<token>
+func parseWebhookURL(webhookURL string) (string, string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", "", errors.Wrap(err, "could not parse webhook url")
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[len(parts)-3] != "webhooks" {
+		return "", "", errors.New("invalid webhook url: %s", webhookURL)
+	}
+
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+func (wh *WebhookNotifier) SendNotification(ctx context.Context, notification Notification) (err error) {
+	ctx, span := otel.Tracer().Start(ctx, "WebhookNotifier.SendNotification",
+		trace.WithAttributes(otel.ChapterAttributes(notification.MangaTitle, notification.ChapterNumber)...))
+	defer func() { otel.EndSpan(span, err) }()
+
+	message := newChapterMessage(wh.cfg, notification)
+	err = wh.execute(ctx, &discordgo.WebhookParams{
+		Content:         message.Content,
+		Embeds:          message.Embeds,
+		AllowedMentions: message.AllowedMentions,
+	})
+	if err != nil {
+		metrics.DiscordError()
+		return fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)
+	}
+
+	state.NotificationSent()
+	return nil
+}
+
+func (wh *WebhookNotifier) SendWarnNotification(ctx context.Context, title string, description string) {
+	wh.send(ctx, newEmbed(Notification{Title: title, Description: description, Color: warnColor}))
+}
+
+func (wh *WebhookNotifier) SendErrorNotification(ctx context.Context, title string, description string,
+	details string) {
+	wh.send(ctx, newEmbed(Notification{Title: title, Description: description, Color: errorColor,
+		Fields: DetailsFields(details), Author: errorAuthor()}))
+}
+
+func (wh *WebhookNotifier) SendCriticalNotification(ctx context.Context, title string, description string) {
+	wh.send(ctx, newEmbed(Notification{Title: title, Description: description, Color: criticalColor,
+		Author: errorAuthor()}))
+}
+
+func (wh *WebhookNotifier) SendResolvedNotification(ctx context.Context, title string, description string,
+	details string) {
+	wh.send(ctx, newEmbed(Notification{Title: title, Description: description, Color: resolvedColor,
+		Fields: DetailsFields(details)}))
+}
+
+func (wh *WebhookNotifier) SendHiatusNotification(ctx context.Context, title string, description string, color int) {
+	wh.send(ctx, newEmbed(Notification{Title: title, Description: description, Color: color}))
+}
+
+// send sends the embed and logs errors, a failed notification must not crash the bot that reports it.
+func (wh *WebhookNotifier) send(ctx context.Context, embed *discordgo.MessageEmbed) {
+	params := &discordgo.WebhookParams{Embeds: []*discordgo.MessageEmbed{embed}}
+	if isPlain(wh.cfg) {
+		params = &discordgo.WebhookParams{Content: embedText(embed)}
+	}
+
+	err := wh.execute(ctx, params)
+	if err != nil && ctx.Err() != nil {
+		wh.log.Warn().Err(err).Msg("Sending Discord webhook notification was cancelled")
+		return
+	}
+	if err != nil {
+		metrics.DiscordError()
+		wh.log.Error().Err(fmt.Errorf("%w: %w", domain.ErrDiscordSendFailure, err)).
+			Msg("error sending Discord webhook notification")
+	}
+}
+
+func (wh *WebhookNotifier) execute(ctx context.Context, params *discordgo.WebhookParams) error {
+	if err := wh.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	_, err := wh.discord.WebhookExecute(wh.webhookID, wh.token, false, params, discordgo.WithContext(ctx))
+	return err
+}
@@ -1,15 +1,239 @@
 package domain
 
-import "sync"
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// ReleaseTimeFormat is the format ChapterInfo.ReleaseTime is stored in, always in UTC.
+	ReleaseTimeFormat = time.RFC3339
+
+	// DisplayTimeFormat is the format release times are shown in, see ChapterInfo.FormattedTime.
+	DisplayTimeFormat = time.RFC1123Z
+
+	// legacyReleaseTimeZone and legacyReleaseTimeFormat describe release times stored by older releases, their
+	// time zone abbreviation is only unambiguous in Europe/Berlin.
+	legacyReleaseTimeZone   = "Europe/Berlin"
+	legacyReleaseTimeFormat = time.RFC1123
+)
+
+// ParseReleaseTime parses ChapterInfo.ReleaseTime, including release times stored by older releases.
+func ParseReleaseTime(releaseTime string) (time.Time, error) {
+	t, err := time.Parse(ReleaseTimeFormat, releaseTime)
+	if err == nil {
+		return t, nil
+	}
+
+	if t, displayErr := time.Parse(DisplayTimeFormat, releaseTime); displayErr == nil {
+		return t, nil
+	}
+
+	location, locationErr := time.LoadLocation(legacyReleaseTimeZone)
+	if locationErr != nil {
+		return time.Time{}, err
+	}
+	if t, legacyErr := time.ParseInLocation(legacyReleaseTimeFormat, releaseTime, location); legacyErr == nil {
+		return t, nil
+	}
+
+	return time.Time{}, err
+}
+
+// FormatReleaseTime formats t for ChapterInfo.ReleaseTime.
+func FormatReleaseTime(t time.Time) string {
+	return t.UTC().Format(ReleaseTimeFormat)
+}
 
 type ChapterInfo struct {
-	ReleaseLink   string
-	MangaTitle    string
-	ChapterNumber string
-	ChapterTitle  string
-	ReleaseTime   string
+	ReleaseLink   string `json:"release_link"`
+	MangaTitle    string `json:"manga_title"`
+	ChapterNumber string `json:"chapter_number"`
+	ChapterTitle  string `json:"chapter_title"`
+	// ReleaseTime is formatted using ReleaseTimeFormat, use FormattedTime to display it
+	ReleaseTime string `json:"release_time"`
+	// AnnouncedAt is zero until the notification for the chapter has been sent
+	AnnouncedAt time.Time `json:"announced_at"`
+	// LastNotifiedAt is zero unless the role of the manga was pinged for the chapter
+	LastNotifiedAt time.Time `json:"last_notified_at"`
 }
 
-var (
-	CollectedChaptersMap sync.Map
-)
+// chapterInfoJSON has the fields of ChapterInfo without its json methods.
+type chapterInfoJSON ChapterInfo
+
+// FormattedTime returns the release time in timeZone, formatted using DisplayTimeFormat. Release times that can't
+// be parsed are returned unchanged and an unknown time zone falls back to UTC.
+func (c ChapterInfo) FormattedTime(timeZone string) string {
+	t, err := ParseReleaseTime(c.ReleaseTime)
+	if err != nil {
+		return c.ReleaseTime
+	}
+
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return t.In(location).Format(DisplayTimeFormat)
+}
+
+// normalized returns the chapter with its release time in ReleaseTimeFormat, release times stored by older releases
+// are converted. Release times that can't be parsed are kept as they are.
+func (c ChapterInfo) normalized() chapterInfoJSON {
+	if t, err := ParseReleaseTime(c.ReleaseTime); err == nil {
+		c.ReleaseTime = FormatReleaseTime(t)
+	}
+
+	return chapterInfoJSON(c)
+}
+
+func (c ChapterInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.normalized())
+}
+
+func (c *ChapterInfo) UnmarshalJSON(data []byte) error {
+	var chapter chapterInfoJSON
+	if err := json.Unmarshal(data, &chapter); err != nil {
+		return err
+	}
+
+	*c = ChapterInfo(ChapterInfo(chapter).normalized())
+	return nil
+}
+
+// CollectedChapter is a collected chapter together with its release title.
+type CollectedChapter struct {
+	ReleaseTitle string `json:"release_title"`
+	ChapterInfo
+}
+
+// collectedChapterJSON is the json format of CollectedChapter, the json methods of the embedded ChapterInfo would
+// drop the release title otherwise.
+type collectedChapterJSON struct {
+	ReleaseTitle string `json:"release_title"`
+	chapterInfoJSON
+}
+
+func (c CollectedChapter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(collectedChapterJSON{ReleaseTitle: c.ReleaseTitle, chapterInfoJSON: c.ChapterInfo.normalized()})
+}
+
+func (c *CollectedChapter) UnmarshalJSON(data []byte) error {
+	var chapter collectedChapterJSON
+	if err := json.Unmarshal(data, &chapter); err != nil {
+		return err
+	}
+
+	c.ReleaseTitle = chapter.ReleaseTitle
+	c.ChapterInfo = ChapterInfo(ChapterInfo(chapter.chapterInfoJSON).normalized())
+	return nil
+}
+
+// ChapterStore holds the collected chapters, keyed by their release title.
+type ChapterStore interface {
+	Store(releaseTitle string, chapter ChapterInfo)
+	Load(releaseTitle string) (ChapterInfo, bool)
+	Delete(releaseTitle string)
+	Range(fn func(releaseTitle string, chapter ChapterInfo) bool)
+}
+
+// SyncMapStore is a ChapterStore backed by a sync.Map, it's safe for concurrent use.
+type SyncMapStore struct {
+	chapters sync.Map
+}
+
+func NewSyncMapStore() *SyncMapStore {
+	return &SyncMapStore{}
+}
+
+func (s *SyncMapStore) Store(releaseTitle string, chapter ChapterInfo) {
+	s.chapters.Store(releaseTitle, chapter)
+}
+
+func (s *SyncMapStore) Load(releaseTitle string) (ChapterInfo, bool) {
+	chapter, ok := s.chapters.Load(releaseTitle)
+	if !ok {
+		return ChapterInfo{}, false
+	}
+
+	return chapter.(ChapterInfo), true
+}
+
+func (s *SyncMapStore) Delete(releaseTitle string) {
+	s.chapters.Delete(releaseTitle)
+}
+
+func (s *SyncMapStore) Range(fn func(releaseTitle string, chapter ChapterInfo) bool) {
+	s.chapters.Range(func(releaseTitle, chapter any) bool {
+		return fn(releaseTitle.(string), chapter.(ChapterInfo))
+	})
+}
+
+// InMemoryStore is a ChapterStore backed by a map, it's meant for tests so they don't share any state. It's safe for
+// concurrent use, fn of Range may modify the store.
+type InMemoryStore struct {
+	m        sync.RWMutex
+	chapters map[string]ChapterInfo
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{chapters: make(map[string]ChapterInfo)}
+}
+
+func (s *InMemoryStore) Store(releaseTitle string, chapter ChapterInfo) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.chapters[releaseTitle] = chapter
+}
+
+func (s *InMemoryStore) Load(releaseTitle string) (ChapterInfo, bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	chapter, ok := s.chapters[releaseTitle]
+	return chapter, ok
+}
+
+func (s *InMemoryStore) Delete(releaseTitle string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.chapters, releaseTitle)
+}
+
+func (s *InMemoryStore) Range(fn func(releaseTitle string, chapter ChapterInfo) bool) {
+	s.m.RLock()
+	chapters := make(map[string]ChapterInfo, len(s.chapters))
+	for releaseTitle, chapter := range s.chapters {
+		chapters[releaseTitle] = chapter
+	}
+	s.m.RUnlock()
+
+	for releaseTitle, chapter := range chapters {
+		if !fn(releaseTitle, chapter) {
+			return
+		}
+	}
+}
+
+// CountChapters returns the number of chapters in the store.
+func CountChapters(store ChapterStore) int {
+	count := 0
+	store.Range(func(_ string, _ ChapterInfo) bool {
+		count++
+		return true
+	})
+
+	return count
+}
+
+// MangaStat summarizes the collected chapters of a manga.
+type MangaStat struct {
+	MangaTitle          string
+	ChapterCount        int
+	FirstChapterTime    time.Time
+	LatestChapterTime   time.Time
+	LatestChapterNumber string
+}
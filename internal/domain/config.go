@@ -1,15 +1,84 @@
 package domain
 
 type Config struct {
-	Version             string
-	ConfigPath          string
-	DiscordToken        string   `toml:"discordToken"`
-	DiscordChannelID    string   `toml:"discordChannelID"`
-	CollectedChaptersDB string   `toml:"collectedChaptersDB"`
-	LogPath             string   `toml:"logPath"`
-	LogLevel            string   `toml:"LogLevel"`
-	LogMaxSize          int      `toml:"logMaxSize"` // in megabytes
-	LogMaxBackups       int      `toml:"logMaxBackups"`
-	WatchedMangas       []string `toml:"watchedMangas"`
-	SleepTimer          int      `toml:"sleepTimer"`
+	Version                   string              `json:"version"`
+	ConfigPath                string              `json:"config_path"`
+	DryRun                    bool                `json:"dry_run"`
+	Verbose                   bool                `json:"verbose"` // only set using the --verbose flag, never from the config file
+	DiscordToken              string              `toml:"discordToken" json:"-"`
+	DiscordChannelID          string              `toml:"discordChannelID" json:"discord_channel_id"`
+	DiscordWebhookURL         string              `toml:"discordWebhookURL" json:"-"`
+	DiscordHiatusChannelID    string              `toml:"discordHiatusChannelID" json:"discord_hiatus_channel_id"`
+	DiscordForumChannelID     string              `toml:"discordForumChannelID" json:"discord_forum_channel_id"`
+	DiscordErrorChannelID     string              `toml:"discordErrorChannelID" json:"discord_error_channel_id"`
+	DiscordWarnChannelID      string              `toml:"discordWarnChannelID" json:"discord_warn_channel_id"`
+	DiscordCriticalChannelID  string              `toml:"discordCriticalChannelID" json:"discord_critical_channel_id"`
+	DiscordRateLimit          int                 `toml:"discordRateLimit" json:"discord_rate_limit"`
+	DiscordFooterIconURL      string              `toml:"discordFooterIconURL" json:"discord_footer_icon_url"`
+	DiscordEmbedAuthorName    string              `toml:"discordEmbedAuthorName" json:"discord_embed_author_name"`
+	DiscordEmbedAuthorURL     string              `toml:"discordEmbedAuthorURL" json:"discord_embed_author_url"`
+	DiscordEmbedAuthorIconURL string              `toml:"discordEmbedAuthorIconURL" json:"discord_embed_author_icon_url"`
+	DiscordReactEmojiEnabled  bool                `toml:"discordReactEmojiEnabled" json:"discord_react_emoji_enabled"`
+	DiscordReactEmoji         string              `toml:"discordReactEmoji" json:"discord_react_emoji"`
+	Notifier                  string              `toml:"notifier" json:"notifier"`
+	NotificationStyle         string              `toml:"notificationStyle" json:"notification_style"`
+	TelegramBotToken          string              `toml:"telegramBotToken" json:"-"`
+	TelegramChatID            string              `toml:"telegramChatID" json:"telegram_chat_id"`
+	TelegramErrorChatID       string              `toml:"telegramErrorChatID" json:"telegram_error_chat_id"`
+	CollectedChaptersDB       string              `toml:"collectedChaptersDB" json:"collected_chapters_db"`
+	DBMaxOpenConns            int                 `toml:"dbMaxOpenConns" json:"db_max_open_conns"`
+	DBMaxIdleConns            int                 `toml:"dbMaxIdleConns" json:"db_max_idle_conns"`
+	LogPath                   string              `toml:"logPath" json:"log_path"`
+	LogLevel                  string              `toml:"LogLevel" json:"log_level"`
+	LogFormat                 string              `toml:"logFormat" json:"log_format"`    // of stderr, the log file is always JSON
+	LogMaxSize                int                 `toml:"logMaxSize" json:"log_max_size"` // in megabytes
+	LogMaxBackups             int                 `toml:"logMaxBackups" json:"log_max_backups"`
+	LogRotateDaily            bool                `toml:"logRotateDaily" json:"log_rotate_daily"`
+	WatchedMangas             []string            `toml:"watchedMangas" json:"watched_mangas"`
+	WatchAll                  bool                `toml:"watchAll" json:"watch_all"`
+	MangaChannels             map[string]string   `toml:"mangaChannels" json:"manga_channels"`
+	MangaRoles                map[string]string   `toml:"mangaRoles" json:"manga_roles"`
+	MangaMessagePrefix        map[string]string   `toml:"mangaMessagePrefix" json:"manga_message_prefix"`
+	MangaMessageSuffix        map[string]string   `toml:"mangaMessageSuffix" json:"manga_message_suffix"`
+	MangaPingCooldownMinutes  int                 `toml:"mangaPingCooldownMinutes" json:"manga_ping_cooldown_minutes"`
+	MangaErrorCooldownMinutes int                 `toml:"mangaErrorCooldownMinutes" json:"manga_error_cooldown_minutes"`
+	MaxNotificationsPerRun    int                 `toml:"maxNotificationsPerRun" json:"max_notifications_per_run"`
+	MangaStartChapter         map[string]string   `toml:"mangaStartChapter" json:"manga_start_chapter"`
+	MangaEndChapter           map[string]string   `toml:"mangaEndChapter" json:"manga_end_chapter"`
+	MangaAliases              map[string][]string `toml:"mangaAliases" json:"manga_aliases"`
+	FuzzyMatch                bool                `toml:"fuzzyMatch" json:"fuzzy_match"`
+	SleepTimer                int                 `toml:"sleepTimer" json:"sleep_timer"`
+	MangaSleepTimers          map[string]int      `toml:"mangaSleepTimers" json:"manga_sleep_timers"`
+	CheckOnStartup            bool                `toml:"checkOnStartup" json:"check_on_startup"`
+	AllowURLRevisit           bool                `toml:"allowURLRevisit" json:"allow_url_revisit"`
+	ScrapeURLs                []string            `toml:"scrapeURLs" json:"scrape_urls" env:"SCRAPE_URLS"`
+	ScrapeProxyURL            string              `toml:"scrapeProxyURL" json:"scrape_proxy_url"`
+	ScrapeTimeoutSeconds      int                 `toml:"scrapeTimeoutSeconds" json:"scrape_timeout_seconds"`
+	ScrapeMaxBodyBytes        int                 `toml:"scrapeMaxBodyBytes" json:"scrape_max_body_bytes"`
+	ScrapeMode                string              `toml:"scrapeMode" json:"scrape_mode"`
+	CacheEnabled              bool                `toml:"cacheEnabled" json:"cache_enabled"`
+	ScrapeCachePath           string              `toml:"scrapeCachePath" json:"scrape_cache_path"`
+	RSSFeedURL                string              `toml:"rssFeedURL" json:"rss_feed_url"`
+	CircuitResetSeconds       int                 `toml:"circuitResetSeconds" json:"circuit_reset_seconds"`
+	UserAgents                []string            `toml:"userAgents" json:"user_agents"`
+	ScrapeHeaders             map[string]string   `toml:"scrapeHeaders" json:"scrape_headers"`
+	APIToken                  string              `toml:"apiToken" json:"-"`
+	SentryDSN                 string              `toml:"sentryDSN" json:"-"`
+	OtelEndpoint              string              `toml:"otelEndpoint" json:"otel_endpoint"`
+	OtelServiceName           string              `toml:"otelServiceName" json:"otel_service_name"`
+	HealthCheckPort           int                 `toml:"healthCheckPort" json:"health_check_port"`
+	ShutdownTimeoutSeconds    int                 `toml:"shutdownTimeoutSeconds" json:"shutdown_timeout_seconds"`
+	DisplayTimezone           string              `toml:"displayTimezone" json:"display_timezone"`
+	QuietHoursStart           string              `toml:"quietHoursStart" json:"quiet_hours_start"`
+	QuietHoursEnd             string              `toml:"quietHoursEnd" json:"quiet_hours_end"`
+	QuietHoursTZ              string              `toml:"quietHoursTZ" json:"quiet_hours_tz"`
+	HiatusThresholdDays       int                 `toml:"hiatusThresholdDays" json:"hiatus_threshold_days"`
+	Milestones                []int               `toml:"milestones" json:"milestones"`
+	EnrichFromAniList         bool                `toml:"enrichFromAniList" json:"enrich_from_anilist" env:"ENRICH_FROM_ANILIST"`
+	NotificationTemplate      string              `toml:"notificationTemplate" json:"notification_template"`
+	MangaColors               map[string]int      `toml:"mangaColors" json:"manga_colors" mapstructure:"-"` // parsed by config.loadMangaColors
+	MangaCovers               map[string]string   `toml:"mangaCovers" json:"manga_covers"`
+	MangaBanners              map[string]string   `toml:"mangaBanners" json:"manga_banners"`
+	SMTP                      SMTPConfig          `toml:"smtp" json:"smtp"`
+	Guilds                    []GuildConfig       `toml:"guilds" json:"guilds"`
 }
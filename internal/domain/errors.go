@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+// Error categories, errors are wrapped with them using fmt.Errorf("%w: %w", category, err), so callers can tell
+// them apart using errors.Is.
+var (
+	ErrScrapeFailure       = errors.New("scrape failure")
+	ErrDiscordSendFailure  = errors.New("discord send failure")
+	ErrDatabaseUnavailable = errors.New("database unavailable")
+	ErrConfigInvalid       = errors.New("invalid config")
+)
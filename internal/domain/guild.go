@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// GuildConfig configures a Discord server with its own channels and watched mangas.
+type GuildConfig struct {
+	GuildID               string   `toml:"guildID" json:"guild_id"`
+	DiscordChannelID      string   `toml:"discordChannelID" json:"discord_channel_id"`
+	DiscordErrorChannelID string   `toml:"discordErrorChannelID" json:"discord_error_channel_id"`
+	WatchedMangas         []string `toml:"watchedMangas" json:"watched_mangas"`
+}
+
+// EffectiveLogLevel returns the configured log level, verbose mode logs at least debug messages.
+func (c *Config) EffectiveLogLevel() string {
+	if c.Verbose && c.LogLevel != "TRACE" {
+		return "DEBUG"
+	}
+
+	return c.LogLevel
+}
+
+// LogFormatConsole writes human-readable logs to stderr instead of JSON.
+const LogFormatConsole = "console"
+
+const (
+	// NotificationStyleEmbed sends notifications as embeds, NotificationStylePlain as plain text messages.
+	NotificationStyleEmbed = "embed"
+	NotificationStylePlain = "plain"
+)
+
+// WatchAllMangas is the watched manga that matches every manga on the site.
+const WatchAllMangas = "*"
+
+// WatchesAll reports whether every manga on the site is watched, either by watchAll or a "*" watched manga.
+func (c *Config) WatchesAll() bool {
+	return c.WatchAll || slices.Contains(c.AllWatchedMangas(), WatchAllMangas)
+}
+
+// AllWatchedMangas returns the mangas watched by any guild, or the global watched mangas if there
+// are no guilds.
+func (c *Config) AllWatchedMangas() []string {
+	if c.WatchAll {
+		return []string{WatchAllMangas}
+	}
+
+	if len(c.Guilds) == 0 {
+		return c.WatchedMangas
+	}
+
+	var watchedMangas []string
+	for _, guild := range c.Guilds {
+		for _, manga := range guild.WatchedMangas {
+			if !slices.ContainsFunc(watchedMangas, func(m string) bool { return strings.EqualFold(m, manga) }) {
+				watchedMangas = append(watchedMangas, manga)
+			}
+		}
+	}
+
+	return watchedMangas
+}
+
+// DiscordChannels returns the IDs of all Discord channels tcb-bot sends to, without duplicates.
+func (c *Config) DiscordChannels() []string {
+	channelIDs := []string{c.DiscordChannelID, c.DiscordHiatusChannelID, c.DiscordForumChannelID,
+		c.DiscordErrorChannelID, c.DiscordWarnChannelID, c.DiscordCriticalChannelID}
+	for _, channelID := range c.MangaChannels {
+		channelIDs = append(channelIDs, channelID)
+	}
+	for _, guild := range c.Guilds {
+		channelIDs = append(channelIDs, guild.DiscordChannelID, guild.DiscordErrorChannelID)
+	}
+
+	var channels []string
+	for _, channelID := range channelIDs {
+		if channelID != "" && !slices.Contains(channels, channelID) {
+			channels = append(channels, channelID)
+		}
+	}
+
+	return channels
+}
@@ -0,0 +1,9 @@
+package domain
+
+// MangaMetadata holds information about a manga that was fetched from AniList.
+type MangaMetadata struct {
+	MangaTitle string
+	CoverImage string
+	Synopsis   string
+	Status     string
+}
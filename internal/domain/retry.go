@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// QueuedNotification is a chapter notification that failed to send and is retried later.
+type QueuedNotification struct {
+	ID            int64
+	ReleaseTitle  string
+	AttemptCount  int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	// Notifiers holds the notifiers of a MultiNotifier that still have to send the notification, empty for all
+	Notifiers []string
+}
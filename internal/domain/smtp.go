@@ -0,0 +1,11 @@
+package domain
+
+// SMTPConfig configures the mail server that email notifications are sent with.
+type SMTPConfig struct {
+	Host     string   `toml:"host" json:"host"`
+	Port     int      `toml:"port" json:"port"`
+	Username string   `toml:"username" json:"username"`
+	Password string   `toml:"password" json:"-"`
+	From     string   `toml:"from" json:"from"`
+	To       []string `toml:"to" json:"to"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// BotState is the state of the bot that is kept across restarts.
+type BotState struct {
+	// LastError is the last notified check error, empty once no manga is failing anymore
+	LastError     string
+	LastErrorTime time.Time
+	// CircuitState is the state of the scrape circuit breaker, see circuitbreaker.State
+	CircuitState string
+}
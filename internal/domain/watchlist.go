@@ -0,0 +1,7 @@
+package domain
+
+// Watchlist is the format used to import and export the watched mangas.
+type Watchlist struct {
+	WatchedMangas []string          `json:"watchedMangas"`
+	MangaChannels map[string]string `json:"mangaChannels"`
+}
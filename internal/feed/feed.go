@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/utils"
+)
+
+const (
+	maxEntries = 50
+	atomNS     = "http://www.w3.org/2005/Atom"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Link    atomLink   `xml:"link"`
+	Author  atomAuthor `xml:"author"`
+	Summary string     `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type feedChapter struct {
+	chapter     domain.ChapterInfo
+	releaseTime time.Time
+}
+
+// Handler returns a handler that serves an Atom feed of the latest collected chapters. Links point to the
+// first scrape url, so the ids of the entries don't change if a mirror is used.
+func Handler(cfg *config.AppConfig, store domain.ChapterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serveFeed(w, cfg.Config.ScrapeURLs[0], latestChapters(store))
+	}
+}
+
+func serveFeed(w http.ResponseWriter, websiteURL string, chapters []feedChapter) {
+	feed := atomFeed{
+		XMLNS:   atomNS,
+		ID:      websiteURL,
+		Title:   "tcb-bot chapter releases",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: websiteURL},
+	}
+	if len(chapters) > 0 {
+		feed.Updated = chapters[0].releaseTime.UTC().Format(time.RFC3339)
+	}
+
+	for _, c := range chapters {
+		summary := fmt.Sprintf("Chapter %s", c.chapter.ChapterNumber)
+		if c.chapter.ChapterTitle != "" {
+			summary = fmt.Sprintf("Chapter %s: %s", c.chapter.ChapterNumber, c.chapter.ChapterTitle)
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      utils.ReleaseURL(websiteURL, c.chapter.ReleaseLink),
+			Title:   fmt.Sprintf("%s Chapter %s", c.chapter.MangaTitle, c.chapter.ChapterNumber),
+			Updated: c.releaseTime.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: utils.ReleaseURL(websiteURL, c.chapter.ReleaseLink)},
+			Author:  atomAuthor{Name: "TCB Scans"},
+			Summary: summary,
+		})
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(output)
+}
+
+// latestChapters returns the most recent collected chapters, sorted by release time descending.
+func latestChapters(store domain.ChapterStore) []feedChapter {
+	var chapters []feedChapter
+	store.Range(func(_ string, chapter domain.ChapterInfo) bool {
+
+		releaseTime, err := domain.ParseReleaseTime(chapter.ReleaseTime)
+		if err != nil {
+			return true
+		}
+
+		chapters = append(chapters, feedChapter{chapter: chapter, releaseTime: releaseTime})
+		return true
+	})
+
+	slices.SortFunc(chapters, func(a, b feedChapter) int {
+		return cmp.Compare(b.releaseTime.UnixNano(), a.releaseTime.UnixNano())
+	})
+
+	if len(chapters) > maxEntries {
+		chapters = chapters[:maxEntries]
+	}
+
+	return chapters
+}
@@ -0,0 +1,127 @@
+package hiatus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	hiatusColor = 16776960
+	backColor   = 3066993
+)
+
+// Checker notifies about watched mangas that haven't had a new chapter for longer than the
+// configured hiatus threshold.
+type Checker struct {
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	chapters domain.ChapterStore
+	notifier discord.Notifier
+	db       *database.DB
+}
+
+func NewChecker(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore, notifier discord.Notifier,
+	db *database.DB) *Checker {
+	return &Checker{
+		log:      log.With().Str("module", "hiatus").Logger(),
+		cfg:      cfg,
+		chapters: chapters,
+		notifier: notifier,
+		db:       db,
+	}
+}
+
+func (c *Checker) Run(ctx context.Context) error {
+	threshold := time.Duration(c.cfg.Config.HiatusThresholdDays) * 24 * time.Hour
+
+	for mangaTitle, latest := range c.latestReleases() {
+		gap := time.Since(latest)
+		if gap < threshold {
+			continue
+		}
+
+		notified, err := c.db.IsHiatusNotified(ctx, mangaTitle)
+		if err != nil {
+			return err
+		}
+		if notified {
+			c.log.Trace().Msgf("Hiatus notification was already sent: %q", mangaTitle)
+			continue
+		}
+
+		c.log.Info().Msgf("Manga seems to be on hiatus: %q", mangaTitle)
+		c.notifier.SendHiatusNotification(ctx, fmt.Sprintf("%s is on hiatus", mangaTitle),
+			fmt.Sprintf("No new chapter has been released for %s.", utils.FormatDuration(gap)), hiatusColor)
+
+		if c.cfg.Config.DryRun {
+			continue
+		}
+
+		if err := c.db.SetHiatusNotified(ctx, mangaTitle, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resume sends the "series is back" notification if a hiatus notification was sent for the manga.
+func (c *Checker) Resume(ctx context.Context, chapter domain.ChapterInfo) {
+	ended, err := c.db.ClearHiatusNotified(ctx, chapter.MangaTitle)
+	if err != nil {
+		c.log.Error().Err(err).Msgf("error clearing hiatus state: %q", chapter.MangaTitle)
+		return
+	}
+	if !ended {
+		return
+	}
+
+	c.log.Info().Msgf("Manga is back from hiatus: %q", chapter.MangaTitle)
+	c.notifier.SendHiatusNotification(ctx, fmt.Sprintf("%s is back!", chapter.MangaTitle),
+		fmt.Sprintf("Chapter %s has been released.", chapter.ChapterNumber), backColor)
+}
+
+// latestReleases returns the release time of the latest collected chapter for each watched manga. Mangas that are only
+// watched because all mangas are watched are skipped, the site has many series that have been finished for years.
+func (c *Checker) latestReleases() map[string]time.Time {
+	latest := make(map[string]time.Time)
+
+	watchedMangas := slices.DeleteFunc(slices.Clone(c.cfg.Config.AllWatchedMangas()), func(watched string) bool {
+		return watched == domain.WatchAllMangas
+	})
+	if len(watchedMangas) == 0 {
+		return latest
+	}
+
+	c.chapters.Range(func(_ string, chapter domain.ChapterInfo) bool {
+		if !slices.ContainsFunc(watchedMangas, func(watched string) bool {
+			return utils.TitleMatches(chapter.MangaTitle, watched, c.cfg.Config.FuzzyMatch)
+		}) {
+			return true
+		}
+
+		releaseTime, err := domain.ParseReleaseTime(chapter.ReleaseTime)
+		if err != nil {
+			c.log.Error().Err(err).Msgf("error parsing release time: %q", chapter.ReleaseTime)
+			return true
+		}
+
+		if releaseTime.After(latest[chapter.MangaTitle]) {
+			latest[chapter.MangaTitle] = releaseTime
+		}
+		return true
+	})
+
+	return latest
+}
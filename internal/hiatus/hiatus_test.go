@@ -0,0 +1,75 @@
+package hiatus
+
+import (
+	"slices"
+	"testing"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/testutils"
+)
+
+func TestLatestReleases(t *testing.T) {
+	chapters := domain.NewInMemoryStore()
+	for releaseTitle, chapter := range map[string]domain.ChapterInfo{
+		"One Piece Chapter 1100":       {MangaTitle: "One Piece", ReleaseTime: "2024-01-05T12:00:00Z"},
+		"One Piece Chapter 1101":       {MangaTitle: "One Piece", ReleaseTime: "2024-01-12T12:00:00Z"},
+		"Jujutsu Kaisen Chapter 250":   {MangaTitle: "Jujutsu Kaisen", ReleaseTime: "2024-01-07T12:00:00Z"},
+		"Black Clover Chapter 370":     {MangaTitle: "Black Clover", ReleaseTime: "2023-10-01T12:00:00Z"},
+		"Chainsaw Man Chapter 150":     {MangaTitle: "Chainsaw Man", ReleaseTime: "2024-01-10T12:00:00Z"},
+		"My Hero Academia Chapter 420": {MangaTitle: "My Hero Academia", ReleaseTime: "2024-05-01T12:00:00Z"},
+	} {
+		chapters.Store(releaseTitle, chapter)
+	}
+
+	tests := []struct {
+		name     string
+		override func(cfg *domain.Config)
+		want     []string
+	}{
+		{
+			name: "watched mangas",
+			override: func(cfg *domain.Config) {
+				cfg.WatchedMangas = []string{"One Piece", "Black Clover"}
+			},
+			want: []string{"Black Clover", "One Piece"},
+		},
+		{
+			name: "watch all",
+			override: func(cfg *domain.Config) {
+				cfg.WatchAll = true
+			},
+		},
+		{
+			name: "wildcard",
+			override: func(cfg *domain.Config) {
+				cfg.WatchedMangas = []string{domain.WatchAllMangas}
+			},
+		},
+		{
+			name: "wildcard in one guild",
+			override: func(cfg *domain.Config) {
+				cfg.Guilds = []domain.GuildConfig{
+					{GuildID: "1", DiscordChannelID: "100000000000000001", WatchedMangas: []string{domain.WatchAllMangas}},
+					{GuildID: "2", DiscordChannelID: "100000000000000002", WatchedMangas: []string{"Chainsaw Man"}},
+				}
+			},
+			want: []string{"Chainsaw Man"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(testutils.NewTestLogger(t), testutils.NewTestConfig(tt.override), chapters, nil, nil)
+
+			var got []string
+			for mangaTitle := range checker.latestReleases() {
+				got = append(got, mangaTitle)
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
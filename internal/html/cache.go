@@ -0,0 +1,181 @@
+package html
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tcb-bot/internal/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// cachedResponse is a response stored in the scrape cache with the headers needed to revalidate it.
+type cachedResponse struct {
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	Header       http.Header `json:"header"`
+	Uncompressed bool        `json:"uncompressed"`
+	Body         []byte      `json:"body"`
+}
+
+// cachingTransport caches responses in dir and revalidates them on every request using their ETag and Last-Modified
+// headers. Pages that weren't modified are answered with the cached response, so they aren't downloaded again.
+//
+// colly's own CacheDir isn't used because it never revalidates, new chapters would never be found.
+type cachingTransport struct {
+	log     zerolog.Logger
+	dir     string
+	maxBody int
+	next    http.RoundTripper
+}
+
+// newCachingTransport returns a cachingTransport that sends its requests using the scrape proxy, if configured.
+func newCachingTransport(log zerolog.Logger, dir string, maxBody int, proxyURL string) (*cachingTransport, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	next := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		u, err := utils.ParseProxyURL(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		next.Proxy = http.ProxyURL(u)
+	}
+
+	return &cachingTransport{log: log, dir: dir, maxBody: maxBody, next: next}, nil
+}
+
+// RoundTrip sends the request, conditionally if a cached response of its url exists.
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	path := t.path(req)
+	cached := t.load(path)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" && req.Header.Get("If-None-Match") == "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		t.log.Trace().Msgf("Using cached response of %s", req.URL)
+		return cached.response(req), nil
+	case resp.StatusCode != http.StatusOK:
+		return resp, nil
+	case noStore(resp) || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == ""):
+		// the response can't be revalidated, an old one must not be used anymore either
+		if cached != nil {
+			t.remove(path)
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(t.maxBody)))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(path, &cachedResponse{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Header:       resp.Header,
+		Uncompressed: resp.Uncompressed,
+		Body:         body,
+	})
+
+	return resp, nil
+}
+
+// path returns the path the response of the request is cached at.
+func (t *cachingTransport) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String()))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns the cached response at path or nil if there is none.
+func (t *cachingTransport) load(path string) *cachedResponse {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.log.Error().Err(err).Msgf("error reading cached response: %s", path)
+		}
+		return nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		t.log.Error().Err(err).Msgf("error decoding cached response: %s", path)
+		return nil
+	}
+
+	return &cached
+}
+
+func (t *cachingTransport) store(path string, cached *cachedResponse) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		t.log.Error().Err(err).Msgf("error encoding cached response: %s", path)
+		return
+	}
+
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		t.log.Error().Err(err).Msgf("error writing cached response: %s", path)
+	}
+}
+
+func (t *cachingTransport) remove(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		t.log.Error().Err(err).Msgf("error removing cached response: %s", path)
+	}
+}
+
+// response returns the cached response as the answer to req.
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Uncompressed:  c.Uncompressed,
+		Request:       req,
+	}
+}
+
+// noStore reports whether the response must not be cached according to its Cache-Control header.
+func noStore(resp *http.Response) bool {
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/gocolly/colly"
+)
+
+const (
+	// ChapterCardSelector matches the chapter cards on the website
+	ChapterCardSelector = "div.bg-card"
+
+	// releaseTitleSelector, chapterTitleSelector and releaseTimeSelector match the values inside a chapter card
+	releaseTitleSelector = "a.text-white.text-lg.font-bold"
+	chapterTitleSelector = "div.mb-3 > div"
+	releaseTimeSelector  = "time-ago"
+)
+
+// ErrNoChapterCards is returned by Preview and ListMangas if the selector didn't match any element.
+var ErrNoChapterCards = errors.New("selector matched no elements")
+
+// ParseChapterCard parses the chapter of a chapter card. The chapter title is empty if the card has none.
+func ParseChapterCard(e *colly.HTMLElement) (domain.ChapterInfo, error) {
+	releaseTitle := e.ChildText(releaseTitleSelector)
+	if releaseTitle == "" {
+		return domain.ChapterInfo{}, errors.New("could not find releaseTitle")
+	}
+
+	releaseLink := e.ChildAttr(releaseTitleSelector, "href")
+	if releaseLink == "" {
+		return domain.ChapterInfo{}, fmt.Errorf("could not find releaseLink: %q", releaseTitle)
+	}
+
+	chapterTitle := e.ChildText(chapterTitleSelector)
+
+	releaseTime := e.ChildAttr(releaseTimeSelector, "datetime")
+	if releaseTime == "" {
+		return domain.ChapterInfo{}, fmt.Errorf("could not find releaseTime: %q", releaseTitle)
+	}
+
+	if !utils.ValidateReleaseTitle(releaseTitle) {
+		return domain.ChapterInfo{}, fmt.Errorf("invalid releaseTitle: %q", releaseTitle)
+	}
+
+	if !utils.ValidateReleaseLink(releaseLink) {
+		return domain.ChapterInfo{}, fmt.Errorf("invalid releaseLink: %q", releaseLink)
+	}
+
+	// Unescape HTML entities
+	releaseTitle = html.UnescapeString(releaseTitle)
+	chapterTitle = html.UnescapeString(chapterTitle)
+
+	mangaTitle, _, chapterNumber, err := utils.ParseReleaseTitle(releaseTitle)
+	if err != nil {
+		return domain.ChapterInfo{}, fmt.Errorf("could not parse releaseTitle %q: %w", releaseTitle, err)
+	}
+
+	released, err := time.Parse(time.RFC3339, releaseTime)
+	if err != nil {
+		return domain.ChapterInfo{}, fmt.Errorf("could not parse releaseTime of %q: %w", releaseTitle, err)
+	}
+
+	return domain.ChapterInfo{
+		ReleaseLink:   releaseLink,
+		MangaTitle:    mangaTitle,
+		ChapterNumber: chapterNumber,
+		ChapterTitle:  chapterTitle,
+		ReleaseTime:   domain.FormatReleaseTime(released),
+	}, nil
+}
+
+// Preview parses the elements matching selector on websiteURL like chapter cards, without saving or announcing
+// them. Elements that can't be parsed are returned as cardErrs, ErrNoChapterCards if nothing matched at all.
+func Preview(log logger.Logger, cfg *config.AppConfig, websiteURL string, selector string) (
+	chapters []domain.ChapterInfo, cardErrs []error, err error) {
+	previewLog := log.With().Str("module", "preview").Logger()
+
+	matched := 0
+	cl := newColly(previewLog, cfg)
+	cl.OnHTML(selector, func(e *colly.HTMLElement) {
+		matched++
+
+		chapter, err := ParseChapterCard(e)
+		if err != nil {
+			cardErrs = append(cardErrs, err)
+			return
+		}
+		chapters = append(chapters, chapter)
+	})
+
+	if err := cl.Visit(websiteURL); err != nil {
+		return nil, nil, err
+	}
+
+	if matched == 0 {
+		return nil, nil, ErrNoChapterCards
+	}
+
+	return chapters, cardErrs, nil
+}
+
+// ListMangas returns the titles of all mangas with a chapter card on the first scrape url that can be visited, sorted
+// alphabetically. Chapter cards that can't be parsed are skipped.
+func ListMangas(log logger.Logger, cfg *config.AppConfig) ([]string, error) {
+	listLog := log.With().Str("module", "list-mangas").Logger()
+
+	var errs []error
+	for _, websiteURL := range cfg.Config.ScrapeURLs {
+		matched := 0
+		titles := make(map[string]struct{})
+
+		cl := newColly(listLog, cfg)
+		cl.OnHTML(ChapterCardSelector, func(e *colly.HTMLElement) {
+			matched++
+
+			chapter, err := ParseChapterCard(e)
+			if err != nil {
+				listLog.Warn().Err(err).Msg("Could not parse chapter card, skipping it")
+				return
+			}
+			titles[chapter.MangaTitle] = struct{}{}
+		})
+
+		if err := cl.Visit(websiteURL); err != nil {
+			listLog.Warn().Err(err).Msgf("Could not visit %s, trying the next scrape url", websiteURL)
+			errs = append(errs, fmt.Errorf("could not visit %s: %w", websiteURL, err))
+			continue
+		}
+
+		if matched == 0 {
+			return nil, ErrNoChapterCards
+		}
+
+		mangas := make([]string, 0, len(titles))
+		for title := range titles {
+			mangas = append(mangas, title)
+		}
+		slices.SortFunc(mangas, func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+
+		return mangas, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,42 @@
+package html
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/hiatus"
+	"tcb-bot/internal/logger"
+)
+
+// FakeCollector is a Collector that serves canned HTML instead of visiting the live site.
+type FakeCollector struct {
+	*Collector
+}
+
+// fakeTransport answers every request with the same HTML page.
+type fakeTransport struct {
+	html string
+}
+
+func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(strings.NewReader(t.html)),
+		Request:    req,
+	}, nil
+}
+
+// NewFakeCollector returns a FakeCollector that's set up like NewCollector, every scrape url is answered with html.
+func NewFakeCollector(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore,
+	notifier discord.Notifier, db *database.DB, hiatus *hiatus.Checker, html string) *FakeCollector {
+	coll := NewCollector(log, cfg, chapters, notifier, db, hiatus)
+	coll.cl.WithTransport(&fakeTransport{html: html})
+
+	return &FakeCollector{Collector: coll}
+}
@@ -0,0 +1,70 @@
+package html
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"tcb-bot/internal/domain"
+)
+
+func TestFakeCollectorCheck(t *testing.T) {
+	page, err := os.ReadFile("testdata/chapters.html")
+	if err != nil {
+		t.Fatalf("could not read fixture: %v", err)
+	}
+
+	coll, store, session := newTestCollector(t, string(page))
+
+	// the previous chapter was announced by an earlier check
+	announced := domain.ChapterInfo{
+		ReleaseLink:   "/chapters/7699/one-piece-chapter-1099",
+		MangaTitle:    "One Piece",
+		ChapterNumber: "1099",
+		ReleaseTime:   "2023-12-29T12:00:00Z",
+		AnnouncedAt:   time.Now(),
+	}
+	store.Store("One Piece Chapter 1099", announced)
+	if err := coll.db.SaveCollectedChapter(context.Background(), "One Piece Chapter 1099", announced); err != nil {
+		t.Fatalf("could not save announced chapter: %v", err)
+	}
+
+	newChapters, err := coll.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() returned an error: %v", err)
+	}
+	if newChapters != 2 {
+		t.Errorf("Check() found %d new chapters, want 2", newChapters)
+	}
+
+	want := []struct {
+		title string
+		url   string
+	}{
+		{title: "One Piece", url: "http://127.0.0.1/chapters/7701/one-piece-chapter-1101"},
+		{title: "Missing chapter title"},
+		{title: "One Piece", url: "http://127.0.0.1/chapters/7700/one-piece-chapter-1100"},
+	}
+	embeds := session.Embeds()
+	if len(embeds) != len(want) {
+		for _, embed := range embeds {
+			t.Logf("sent %q %s", embed.Title, embed.URL)
+		}
+		t.Fatalf("sent %d notifications, want %d", len(embeds), len(want))
+	}
+	for i, embed := range embeds {
+		if embed.Title != want[i].title || embed.URL != want[i].url {
+			t.Errorf("notification %d is %q %s, want %q %s", i, embed.Title, embed.URL, want[i].title, want[i].url)
+		}
+	}
+
+	for _, releaseTitle := range []string{"One Piece Chapter 1101", "One Piece Chapter 1100"} {
+		if chapter, ok := store.Load(releaseTitle); !ok || chapter.AnnouncedAt.IsZero() {
+			t.Errorf("%q is not marked as announced", releaseTitle)
+		}
+	}
+	if _, ok := store.Load("Chainsaw Man Chapter 150"); ok {
+		t.Error("chapter of a manga that isn't watched was collected")
+	}
+}
@@ -1,157 +1,779 @@
 package html
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"html"
+	"net/http"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"tcb-bot/internal/anilist"
+	"tcb-bot/internal/circuitbreaker"
 	"tcb-bot/internal/config"
 	"tcb-bot/internal/database"
 	"tcb-bot/internal/discord"
 	"tcb-bot/internal/domain"
+	"tcb-bot/internal/hiatus"
 	"tcb-bot/internal/logger"
+	"tcb-bot/internal/otel"
+	"tcb-bot/internal/rss"
+	"tcb-bot/internal/state"
 	"tcb-bot/internal/utils"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/gocolly/colly"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	WebsiteURL = "https://tcbscans.me"
+	// circuitFailureThreshold is the number of consecutive failed scrapes that open the circuit
+	circuitFailureThreshold = 3
+
+	// FooterIconURL is shown next to the footer of chapter notifications, unless discordFooterIconURL is set
+	FooterIconURL = "https://tcbscans.me/favicon.ico"
 )
 
+// unsupportedScrapeHeaders are managed by tcb-bot or colly and can't be set using scrapeHeaders.
+var unsupportedScrapeHeaders = []string{"Content-Type", "User-Agent"}
+
+// ErrCircuitOpen is returned instead of checking while the website is considered down.
+var ErrCircuitOpen = errors.New("circuit is open, skipping check")
+
+// Scraper is implemented by everything that can check a source for new chapter releases.
+type Scraper interface {
+	Run(ctx context.Context) error
+}
+
 type Collector struct {
-	log zerolog.Logger
-	cfg *config.AppConfig
-	bot *discord.Bot
-	db  *database.DB
-	cl  *colly.Collector
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	chapters domain.ChapterStore
+	notifier discord.Notifier
+	db       *database.DB
+	hiatus   *hiatus.Checker
+	anilist  *anilist.Client
+	cl       *colly.Collector
+	rss      *rss.Collector
+	breaker  *circuitbreaker.CircuitBreaker
+
+	// m makes sure only one check runs at a time, ctx is the context of the running check, mangas are
+	// the mangas it checks and newChapters counts the chapters it found. notified counts the notifications it sent
+	// and suppressed holds the chapters that weren't announced because of maxNotificationsPerRun.
+	m           sync.Mutex
+	ctx         context.Context
+	mangas      []string
+	newChapters int
+	notified    int
+	suppressed  map[string]struct{}
+
+	// websiteURL is the scrape url that is visited by the running check, activeURL the last one that worked
+	websiteURL string
+	activeURL  string
+
+	// reportedGaps holds the last reported chapter gaps per manga so they are only sent once
+	reportedGaps map[string]string
+
+	// onChapterFound, onScrapeError and onScrapeDone are called for every announced chapter, every failed check and
+	// every finished check, if they're set
+	onChapterFound func(chapter domain.ChapterInfo)
+	onScrapeError  func(err error)
+	onScrapeDone   func(duration time.Duration, err error)
 }
 
-func NewCollector(log logger.Logger, cfg *config.AppConfig, bot *discord.Bot, db *database.DB) *Collector {
+func NewCollector(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore, notifier discord.Notifier,
+	db *database.DB, hiatus *hiatus.Checker) *Collector {
 	log.Trace().Msg("Creating new collector")
-	collector := colly.NewCollector(
-		colly.AllowURLRevisit(),
+	collLog := log.With().Str("module", "collector").Logger()
+
+	coll := &Collector{
+		log:      collLog,
+		cfg:      cfg,
+		chapters: chapters,
+		notifier: notifier,
+		db:       db,
+		hiatus:   hiatus,
+		anilist:  anilist.NewClient(log, cfg),
+		cl:       newColly(collLog, cfg),
+		breaker: circuitbreaker.New(circuitFailureThreshold,
+			time.Duration(cfg.Config.CircuitResetSeconds)*time.Second),
+
+		reportedGaps: make(map[string]string),
+	}
+	coll.rss = rss.NewCollector(log, cfg, func(ctx context.Context, chapter domain.ChapterInfo) {
+		// a single broken feed item must not stop the others from being processed
+		utils.SafeGo(func() { coll.processChapter(ctx, chapter, nil) }, coll.log, coll.notifier)
+	})
+	coll.registerCallbacks()
+
+	// registered last, so the logged requests contain the user agent that is actually sent
+	if cfg.Config.Verbose {
+		coll.registerVerboseCallbacks()
+	}
+
+	return coll
+}
+
+// newColly creates the colly collector used for scraping, configured with the scrape options of the config.
+func newColly(log zerolog.Logger, cfg *config.AppConfig) *colly.Collector {
+	options := []func(*colly.Collector){
+		// only used if no user agents are configured
 		colly.UserAgent("Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; Googlebot/2.1; +http://www.google.com/bot.html) Chrome/124.0.6367.61 Safari/537.36"),
 
 		// don't restrict allowed domains for the time being
 		// colly.AllowedDomains("tcbscans.me"),
-	)
+	}
+
+	if cfg.Config.AllowURLRevisit {
+		options = append(options, colly.AllowURLRevisit())
+	}
+
+	collector := colly.NewCollector(options...)
+
+	collector.SetRequestTimeout(time.Duration(cfg.Config.ScrapeTimeoutSeconds) * time.Second)
+	collector.MaxBodySize = cfg.Config.ScrapeMaxBodyBytes
+
+	if cfg.Config.ScrapeProxyURL != "" {
+		if err := collector.SetProxy(cfg.Config.ScrapeProxyURL); err != nil {
+			log.Error().Err(err).Msg("error setting scrape proxy")
+		}
+	}
 
-	collector.SetRequestTimeout(120 * time.Second)
+	if cfg.Config.CacheEnabled {
+		transport, err := newCachingTransport(log, cfg.Config.ScrapeCachePath, cfg.Config.ScrapeMaxBodyBytes,
+			cfg.Config.ScrapeProxyURL)
+		if err != nil {
+			log.Error().Err(err).Msg("error setting up scrape cache, scraping without it")
+		} else {
+			collector.WithTransport(transport)
+		}
+	}
+
+	if len(cfg.Config.UserAgents) > 0 {
+		collector.OnRequest(func(r *colly.Request) {
+			r.Headers.Set("User-Agent", utils.RandomFrom(cfg.Config.UserAgents))
+		})
+	}
+
+	if headers := scrapeHeaders(log, cfg.Config.ScrapeHeaders); len(headers) > 0 {
+		collector.OnRequest(func(r *colly.Request) {
+			for name, value := range headers {
+				r.Headers.Set(name, value)
+			}
+		})
+	}
+
+	return collector
+}
+
+// scrapeHeaders returns the configured scrape headers without those managed by tcb-bot or colly itself.
+func scrapeHeaders(log zerolog.Logger, configured map[string]string) map[string]string {
+	headers := make(map[string]string, len(configured))
+	for name, value := range configured {
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(unsupportedScrapeHeaders, name) {
+			log.Warn().Msgf("Scrape header %s is not supported, skipping it", name)
+			continue
+		}
+		headers[name] = value
+	}
+
+	return headers
+}
+
+// WithChapterFoundHook sets a function that's called for every chapter whose notification was sent, including
+// notifications that were retried.
+func (coll *Collector) WithChapterFoundHook(fn func(chapter domain.ChapterInfo)) *Collector {
+	coll.onChapterFound = fn
+	return coll
+}
+
+// WithScrapeErrorHook sets a function that's called with the error of every failed check, except cancelled checks and
+// checks skipped because the circuit is open.
+func (coll *Collector) WithScrapeErrorHook(fn func(err error)) *Collector {
+	coll.onScrapeError = fn
+	return coll
+}
+
+// WithScrapeDoneHook sets a function that's called with the duration and error of every finished check, except
+// cancelled checks and checks skipped because the circuit is open.
+func (coll *Collector) WithScrapeDoneHook(fn func(duration time.Duration, err error)) *Collector {
+	coll.onScrapeDone = fn
+	return coll
+}
+
+// WithCircuitStateHook sets a function that's called with the new state whenever the state of the circuit changes.
+func (coll *Collector) WithCircuitStateHook(fn func(circuitState string)) *Collector {
+	coll.breaker.OnStateChange(func(circuitState circuitbreaker.State) { fn(circuitState.String()) })
+	return coll
+}
+
+// RestoreCircuitState restores the state of the circuit saved by an earlier run, unknown states are ignored.
+func (coll *Collector) RestoreCircuitState(circuitState string) {
+	restored, ok := circuitbreaker.ParseState(circuitState)
+	if !ok {
+		return
+	}
+
+	coll.breaker.Restore(restored)
+	state.SetCircuitState(restored.String())
+}
 
-	return &Collector{
-		log: log.With().Str("module", "collector").Logger(),
-		cfg: cfg,
-		bot: bot,
-		db:  db,
-		cl:  collector,
+func (coll *Collector) chapterFound(chapter domain.ChapterInfo) {
+	if coll.onChapterFound != nil {
+		coll.onChapterFound(chapter)
 	}
 }
 
-func (coll *Collector) Run() error {
-	coll.cl.OnHTML("div.bg-card", func(e *colly.HTMLElement) {
-		coll.processHTMLElement(e)
+func (coll *Collector) registerCallbacks() {
+	// colly doesn't support contexts, so requests of a cancelled check are aborted before they're sent
+	coll.cl.OnRequest(func(r *colly.Request) {
+		if coll.ctx != nil && coll.ctx.Err() != nil {
+			coll.log.Debug().Msgf("Check was cancelled, aborting request: %s", r.URL)
+			r.Abort()
+		}
 	})
 
-	coll.log.Trace().Msg("Checking new releases for titles matching watched mangas...")
-	err := coll.cl.Visit(WebsiteURL)
+	coll.cl.OnHTML(ChapterCardSelector, func(e *colly.HTMLElement) {
+		// a single broken chapter card must not stop the others from being processed
+		utils.SafeGo(func() { coll.processHTMLElement(e) }, coll.log, coll.notifier)
+	})
+}
+
+func (coll *Collector) Run(ctx context.Context) error {
+	_, err := coll.Check(ctx)
+	return err
+}
+
+// Check runs a single check for all watched mangas and returns the number of new chapters that were found.
+func (coll *Collector) Check(ctx context.Context) (int, error) {
+	return coll.check(ctx, coll.cfg.Config.AllWatchedMangas())
+}
+
+// CheckManga runs a single check that only looks for new chapters of the given manga.
+func (coll *Collector) CheckManga(ctx context.Context, mangaTitle string) (int, error) {
+	return coll.check(ctx, []string{mangaTitle})
+}
+
+func (coll *Collector) check(ctx context.Context, mangas []string) (newChapters int, err error) {
+	coll.m.Lock()
+	defer coll.m.Unlock()
+
+	// every check is a trace, from visiting the website to sending the notifications
+	ctx, span := otel.Tracer().Start(ctx, "Collector.Check")
+	defer func() { otel.EndSpan(span, err) }()
+
+	coll.ctx = ctx
+	coll.mangas = mangas
+	coll.newChapters = 0
+	coll.notified = 0
+	coll.suppressed = make(map[string]struct{})
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if !coll.breaker.Allow() {
+		coll.log.Debug().Msg("Circuit is open, skipping check")
+		return 0, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	err = coll.scrape(ctx)
 	if err != nil {
-		return err
+		err = fmt.Errorf("%w: %w", domain.ErrScrapeFailure, err)
+	}
+
+	// a cancelled check says nothing about the website
+	if ctx.Err() != nil {
+		return coll.newChapters, ctx.Err()
+	}
+
+	if coll.onScrapeDone != nil {
+		coll.onScrapeDone(time.Since(start), err)
+	}
+	if err != nil && coll.onScrapeError != nil {
+		coll.onScrapeError(err)
+	}
+
+	// only failures of the website itself open the circuit
+	if errors.Is(err, domain.ErrScrapeFailure) {
+		if coll.breaker.Failure() {
+			coll.log.Warn().Msgf("Circuit opened after %d failed checks", circuitFailureThreshold)
+			coll.notifier.SendErrorNotification(ctx, "Circuit open", fmt.Sprintf(
+				"Checking failed %d times in a row, skipping checks for %d seconds.", circuitFailureThreshold,
+				coll.cfg.Config.CircuitResetSeconds), "")
+		}
+	} else if err == nil {
+		coll.breaker.Success()
 	}
+	state.SetCircuitState(coll.breaker.State().String())
 
-	return nil
+	if err == nil {
+		state.SetLastScrapeTime(time.Now())
+		coll.checkGaps(ctx)
+	}
+
+	if suppressed := len(coll.suppressed); suppressed > 0 {
+		coll.log.Warn().Msgf("Reached maxNotificationsPerRun, suppressed %d notifications", suppressed)
+		coll.notifier.SendWarnNotification(ctx, "Notifications suppressed", fmt.Sprintf(
+			"Suppressed %d additional notifications to prevent spam. Run again to receive more.", suppressed))
+	}
+
+	return coll.newChapters, err
 }
 
-func (coll *Collector) processHTMLElement(e *colly.HTMLElement) {
-	coll.log.Debug().Msg("Finding values for releaseTitle, releaseLink, chapterTitle and releaseTime")
-	releaseTitle := e.ChildText("a.text-white.text-lg.font-bold")
-	if releaseTitle == "" {
-		coll.log.Error().Msg("error finding value for releaseTitle")
-		return
+// checkGaps sends a single warning for all watched mangas with newly skipped chapter numbers.
+func (coll *Collector) checkGaps(ctx context.Context) {
+	var lines []string
+	for _, mangaTitle := range coll.mangas {
+		gaps, err := coll.db.FindGaps(ctx, mangaTitle)
+		if err != nil {
+			coll.log.Error().Err(err).Msgf("error finding chapter gaps: %q", mangaTitle)
+			continue
+		}
+
+		missing := strings.Join(gaps, ", ")
+		if missing == coll.reportedGaps[mangaTitle] {
+			continue
+		}
+		coll.reportedGaps[mangaTitle] = missing
+
+		if missing != "" {
+			coll.log.Warn().Msgf("Found chapter gaps for %q: %s", mangaTitle, missing)
+			lines = append(lines, fmt.Sprintf("**%s**: %s", mangaTitle, missing))
+		}
 	}
 
-	releaseLink := e.ChildAttr("a.text-white.text-lg.font-bold", "href")
-	if releaseLink == "" {
-		coll.log.Error().Msgf("error finding value for releaseLink: %q", releaseTitle)
-		return
+	if len(lines) > 0 {
+		coll.notifier.SendWarnNotification(ctx, "Chapter gaps detected",
+			"The following chapters were never seen and might have been missed:\n"+strings.Join(lines, "\n"))
 	}
+}
 
-	chapterTitle := e.ChildText("div.mb-3 > div")
-	if chapterTitle == "" {
-		coll.log.Debug().Msgf("coudln't find value for chapterTitle: %q", releaseTitle)
+// scrape checks the sources of the configured scrape mode. In "both" mode, chapters found in both sources are
+// only announced once, because the second one finds them in the collected chapters.
+func (coll *Collector) scrape(ctx context.Context) error {
+	switch coll.cfg.Config.ScrapeMode {
+	case "rss":
+		return coll.rss.Run(ctx)
+	case "both":
+		return errors.Join(coll.visit(ctx), coll.rss.Run(ctx))
+	default:
+		return coll.visit(ctx)
 	}
+}
 
-	releaseTime := e.ChildAttr("time-ago", "datetime")
-	if releaseTime == "" {
-		coll.log.Error().Msgf("error finding value for releaseTime: %q", releaseTitle)
-		return
+// visit scrapes the scrape urls in order until one of them works.
+func (coll *Collector) visit(ctx context.Context) error {
+	coll.log.Trace().Msg("Checking new releases for titles matching watched mangas...")
+
+	var errs []error
+	for i, websiteURL := range coll.cfg.Config.ScrapeURLs {
+		coll.websiteURL = websiteURL
+
+		// colly calls the callbacks while visiting, so the chapter cards use the context of the visit span
+		visitCtx, span := otel.Tracer().Start(ctx, "colly.Visit", trace.WithAttributes(attribute.String("url", websiteURL)))
+		coll.ctx = visitCtx
+		err := coll.cl.Visit(websiteURL)
+		coll.ctx = ctx
+		otel.EndSpan(span, err)
+
+		if err != nil && !errors.Is(err, colly.ErrAlreadyVisited) {
+			if i < len(coll.cfg.Config.ScrapeURLs)-1 {
+				coll.log.Warn().Err(err).Msgf("Could not visit %s, trying the next scrape url", websiteURL)
+			}
+			errs = append(errs, fmt.Errorf("could not visit %s: %w", websiteURL, err))
+			continue
+		}
+
+		// only happens if URL revisits are disabled, nothing to check until the next session
+		if err != nil {
+			coll.log.Trace().Msgf("Already visited %s during this session, skipping", websiteURL)
+		}
+
+		if websiteURL != coll.activeURL {
+			if i > 0 {
+				coll.log.Info().Msgf("Using mirror %s, the previous scrape urls are unreachable", websiteURL)
+			} else if coll.activeURL != "" {
+				coll.log.Info().Msgf("Using %s again", websiteURL)
+			}
+			coll.activeURL = websiteURL
+		}
+
+		return nil
 	}
 
-	coll.log.Debug().Msgf("Found: %s // %s // %s // %s", releaseTitle, releaseLink, chapterTitle, releaseTime)
+	return errors.Join(errs...)
+}
+
+func (coll *Collector) processHTMLElement(e *colly.HTMLElement) {
+	ctx, span := otel.Tracer().Start(coll.ctx, "Collector.processHTMLElement")
+	defer span.End()
 
-	coll.log.Trace().Msgf("Validating scraped release title: %q", releaseTitle)
-	if !utils.ValidateReleaseTitle(releaseTitle) {
-		coll.log.Error().Msgf("error validating releaseTitle: %q", releaseTitle)
+	chapter, err := ParseChapterCard(e)
+	if err != nil {
+		coll.log.Error().Err(err).Msg("error parsing chapter card")
 		return
 	}
+	span.SetAttributes(otel.ChapterAttributes(chapter.MangaTitle, chapter.ChapterNumber)...)
 
-	coll.log.Trace().Msgf("Validating scraped release link: %q", releaseLink)
-	if !utils.ValidateReleaseLink(releaseLink) {
-		coll.log.Error().Msgf("error validating releaseLink: %q", releaseLink)
-		return
+	releaseTitle := html.UnescapeString(e.ChildText(releaseTitleSelector))
+	coll.log.Debug().Msgf("Found: %s // %s // %s // %s", releaseTitle, chapter.ReleaseLink, chapter.ChapterTitle,
+		chapter.FormattedTime(coll.cfg.Config.DisplayTimezone))
+	if chapter.ChapterTitle == "" {
+		coll.log.Debug().Msgf("coudln't find value for chapterTitle: %q", releaseTitle)
+	}
+
+	announced := coll.processChapter(ctx, chapter, func() string { return coll.fetchThumbnailURL(ctx, e) })
+	// feeds have no chapter titles, only chapter cards are expected to have one
+	if announced && chapter.ChapterTitle == "" {
+		coll.notifier.SendWarnNotification(ctx, "Missing chapter title",
+			fmt.Sprintf("Couldn't find the title of %s, the notification was sent without it.", releaseTitle))
 	}
+}
 
-	// Unescape HTML entities
-	releaseTitle = html.UnescapeString(releaseTitle)
-	chapterTitle = html.UnescapeString(chapterTitle)
+// processChapter announces the chapter if it belongs to a watched manga and wasn't announced yet and reports
+// whether it was announced. thumbnailURL returns the thumbnail of the source, it's nil if the source has none.
+func (coll *Collector) processChapter(ctx context.Context, newChapter domain.ChapterInfo, thumbnailURL func() string) bool {
+	mangaTitle := newChapter.MangaTitle
 
-	mangaTitle := strings.Trim(strings.Split(releaseTitle, "Chapter")[0], " ")
-	chapterNumber := strings.Trim(strings.Split(releaseTitle, "Chapter")[1], " ")
+	// chapters released under an alias are stored under the canonical title, so they aren't announced twice
+	if canonical := utils.CanonicalTitle(mangaTitle, coll.cfg.Config.MangaAliases, coll.mangas); canonical != mangaTitle {
+		coll.log.Trace().Msgf("Manga title is an alias of %q: %q", canonical, mangaTitle)
+		mangaTitle = canonical
+	}
+	newChapter.MangaTitle = mangaTitle
 
-	cleanRlsTitle := fmt.Sprintf("%s Chapter %s", mangaTitle, chapterNumber)
+	cleanRlsTitle := fmt.Sprintf("%s Chapter %s", mangaTitle, newChapter.ChapterNumber)
 
 	coll.log.Trace().Msgf("Checking if manga is on watchlist: %q", mangaTitle)
-	if !slices.Contains(coll.cfg.Config.WatchedMangas, mangaTitle) {
+	if !slices.ContainsFunc(coll.mangas, func(watched string) bool {
+		return utils.TitleMatches(mangaTitle, watched, coll.cfg.Config.FuzzyMatch)
+	}) {
 		coll.log.Trace().Msgf("Manga is not on watchlist: %q", mangaTitle)
-		return
+		return false
 	}
 
-	coll.log.Trace().Msgf("Checking if chapter was already collected: %q", cleanRlsTitle)
-	_, ok := domain.CollectedChaptersMap.Load(cleanRlsTitle)
-	if ok {
-		coll.log.Trace().Msgf("Chapter was already collected, not sending notification: %q", cleanRlsTitle)
-		return
+	coll.log.Trace().Msgf("Checking if chapter was already announced: %q", cleanRlsTitle)
+	if collected, ok := coll.chapters.Load(cleanRlsTitle); ok {
+		// chapters that were collected but never announced, e.g. because of a crash, are announced again
+		if !collected.AnnouncedAt.IsZero() {
+			coll.log.Trace().Msgf("Chapter was already announced, not sending notification: %q", cleanRlsTitle)
+			return false
+		}
 	}
 
-	formattedTime, err := utils.ParseAndConvertTime(releaseTime, time.RFC3339, "Europe/Berlin", time.RFC1123)
+	firstChapter, err := coll.db.IsFirstChapterForManga(ctx, mangaTitle)
 	if err != nil {
-		coll.log.Fatal().Err(err).Msgf("error parsing release time: %q", cleanRlsTitle)
+		coll.log.Error().Err(err).Msgf("error checking if chapter is the first of the manga: %q", cleanRlsTitle)
+	}
+
+	if start, ok := utils.LookupTitle(coll.cfg.Config.MangaStartChapter, mangaTitle); ok &&
+		!utils.ChapterInRange(newChapter.ChapterNumber, start, "") {
+		coll.log.Trace().Msgf("Chapter is before the start chapter %s, skipping: %q", start, cleanRlsTitle)
+		return false
+	}
+
+	if end, ok := utils.LookupTitle(coll.cfg.Config.MangaEndChapter, mangaTitle); ok &&
+		!utils.ChapterInRange(newChapter.ChapterNumber, "", end) {
+		coll.log.Debug().Msgf("Chapter is after the end chapter %s, not sending notification: %q", end, cleanRlsTitle)
+		if coll.cfg.Config.DryRun {
+			return false
+		}
+
+		// the chapter is marked as announced, so later checks don't pick it up again
+		newChapter.AnnouncedAt = time.Now()
+		coll.chapters.Store(cleanRlsTitle, newChapter)
+		if err := coll.db.SaveCollectedChapter(ctx, cleanRlsTitle, newChapter); err != nil {
+			coll.log.Error().Err(err).Msgf("error saving collected chapter: %q", cleanRlsTitle)
+		}
+		return false
+	}
+
+	if coll.cfg.Config.DryRun {
+		coll.newChapters++
+		coll.log.Info().Msgf("Dry run, would announce: %q released at %s %s", cleanRlsTitle,
+			newChapter.FormattedTime(coll.cfg.Config.DisplayTimezone),
+			coll.releaseURL(newChapter.ReleaseLink))
+		return false
+	}
+
+	// a chapter found on the website and in the feed is only suppressed once
+	if _, ok := coll.suppressed[cleanRlsTitle]; ok {
+		return false
 	}
 
 	coll.log.Trace().Msgf("Adding chapter to collected chapters: %q", cleanRlsTitle)
-	newChapter := domain.ChapterInfo{
-		ReleaseLink:   releaseLink,
-		MangaTitle:    mangaTitle,
-		ChapterNumber: chapterNumber,
-		ChapterTitle:  chapterTitle,
-		ReleaseTime:   formattedTime,
+	coll.chapters.Store(cleanRlsTitle, newChapter)
+	coll.newChapters++
+
+	if err := coll.db.SaveCollectedChapter(ctx, cleanRlsTitle, newChapter); err != nil {
+		coll.log.Error().Err(err).Msgf("error saving collected chapter: %q", cleanRlsTitle)
+		if errors.Is(err, domain.ErrDatabaseUnavailable) {
+			coll.notifier.SendCriticalNotification(ctx, "Database unreachable",
+				fmt.Sprintf("Could not save %s: %v", cleanRlsTitle, err))
+		} else {
+			coll.notifier.SendErrorNotification(ctx, "Error saving chapter",
+				fmt.Sprintf("Could not save %s: %v", cleanRlsTitle, err), "")
+		}
 	}
 
-	domain.CollectedChaptersMap.Store(cleanRlsTitle, newChapter)
+	// the chapter stays unannounced, so a later check announces it
+	if limit := coll.cfg.Config.MaxNotificationsPerRun; limit > 0 && coll.notified >= limit {
+		coll.log.Debug().Msgf("Reached maxNotificationsPerRun, not sending notification: %q", cleanRlsTitle)
+		coll.suppressed[cleanRlsTitle] = struct{}{}
+		return false
+	}
 
-	var desc string
-	if newChapter.ChapterTitle == "" {
-		desc = fmt.Sprintf("Chapter %s\n", newChapter.ChapterNumber)
-	} else {
-		desc = fmt.Sprintf("Chapter %s: %s\n", newChapter.ChapterNumber, newChapter.ChapterTitle)
+	now := time.Now()
+	silent := coll.inPingCooldown(newChapter.MangaTitle, now)
+	if silent {
+		coll.log.Debug().Msgf("Manga is in its ping cooldown, not pinging its role: %q", cleanRlsTitle)
+	}
+	notification := coll.newNotification(ctx, cleanRlsTitle, newChapter, thumbnailURL, silent, firstChapter)
+
+	// chapters of a cancelled check stay unannounced and are announced by the next check
+	if ctx.Err() != nil {
+		coll.log.Debug().Msgf("Check was cancelled, not sending notification: %q", cleanRlsTitle)
+		return false
 	}
 
 	// Send notification to Discord
+	coll.notified++
 	coll.log.Trace().Msgf("Sending notification to discord: %q", cleanRlsTitle)
-	coll.bot.SendDiscordNotification(newChapter.MangaTitle, desc, WebsiteURL+newChapter.ReleaseLink,
-		"Released at "+newChapter.ReleaseTime, 3447003)
-	coll.log.Info().Msgf("Sent notification for: %q", cleanRlsTitle)
+	// the chapter is still marked as announced if it wasn't sent, from now on the retry queue is responsible for it
+	var held *discord.HeldError
+	if err := coll.notifier.SendNotification(ctx, notification); errors.As(err, &held) {
+		coll.log.Info().Msgf("Quiet hours are active, holding back notification until %s: %q",
+			held.Until.Format(time.RFC1123), cleanRlsTitle)
+		coll.queueRetry(context.WithoutCancel(ctx), cleanRlsTitle, held.Until, nil)
+	} else if err != nil {
+		// with several notifiers, only the ones that failed retry it
+		coll.log.Error().Err(err).Msgf("error sending notification, retrying later: %q", cleanRlsTitle)
+		coll.queueRetry(context.WithoutCancel(ctx), cleanRlsTitle, now.Add(retryBackoff(0)), discord.FailedNotifiers(err))
+	} else {
+		coll.log.Info().Msgf("Sent notification for: %q", cleanRlsTitle)
+		if !silent {
+			newChapter.LastNotifiedAt = now
+		}
+		coll.chapterFound(newChapter)
+	}
+
+	newChapter.AnnouncedAt = now
+	coll.chapters.Store(cleanRlsTitle, newChapter)
+	// the chapter was announced, so it's marked as announced even if the check is cancelled by now
+	if err := coll.db.SaveCollectedChapter(context.WithoutCancel(ctx), cleanRlsTitle, newChapter); err != nil {
+		coll.log.Error().Err(err).Msgf("error saving announced chapter: %q", cleanRlsTitle)
+	}
+
+	coll.hiatus.Resume(ctx, newChapter)
+
+	return true
+}
+
+// newNotification builds the notification of a chapter. thumbnailURL returns the thumbnail of the source, it's nil
+// if the source has none. The first chapter of a manga is announced as a newly added manga.
+func (coll *Collector) newNotification(ctx context.Context, cleanRlsTitle string, newChapter domain.ChapterInfo,
+	thumbnailURL func() string, silent, firstChapter bool) discord.Notification {
+	releaseTime := newChapter.FormattedTime(coll.cfg.Config.DisplayTimezone)
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Chapter", Value: newChapter.ChapterNumber, Inline: true},
+		{Name: "Released", Value: releaseTime, Inline: true},
+	}
+
+	desc, err := coll.cfg.RenderNotification(newChapter)
+	if err != nil {
+		coll.log.Error().Err(err).Msgf("error rendering notification: %q", cleanRlsTitle)
+		desc = fmt.Sprintf("Chapter %s\n", newChapter.ChapterNumber)
+	}
+
+	// configured covers take precedence over the chapter card and AniList
+	thumbnail, hasCover := utils.LookupTitle(coll.cfg.Config.MangaCovers, newChapter.MangaTitle)
+	if !hasCover && thumbnailURL != nil {
+		thumbnail = thumbnailURL()
+	}
+	bannerURL, _ := utils.LookupTitle(coll.cfg.Config.MangaBanners, newChapter.MangaTitle)
+	footer := "Released at " + releaseTime
+
+	var synopsis string
+	if coll.cfg.Config.EnrichFromAniList {
+		if metadata, ok := coll.mangaMetadata(ctx, newChapter.MangaTitle); ok {
+			if metadata.CoverImage != "" && !hasCover {
+				thumbnail = metadata.CoverImage
+			}
+			if metadata.Status != "" {
+				fields = append(fields, &discordgo.MessageEmbedField{Name: "Status", Value: metadata.Status, Inline: true})
+			}
+			synopsis = metadata.Synopsis
+		}
+	}
+
+	// the inline fields are shown in a row, the synopsis below them
+	if field := coll.waitSinceLastChapter(ctx, newChapter); field != nil {
+		fields = append(fields, field)
+	}
+	if synopsis != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Synopsis", Value: synopsis})
+	}
+
+	color := 3447003
+	if mangaColor, ok := utils.LookupTitle(coll.cfg.Config.MangaColors, newChapter.MangaTitle); ok {
+		color = mangaColor
+	}
+	if utils.IsMilestone(newChapter.ChapterNumber, coll.cfg.Config.Milestones) {
+		coll.log.Debug().Msgf("Chapter is a milestone: %q", cleanRlsTitle)
+		footer = "🎉 Milestone chapter! " + footer
+		color = 0xFFD700
+	}
+
+	title := newChapter.MangaTitle
+	if firstChapter {
+		coll.log.Debug().Msgf("Chapter is the first of the manga: %q", cleanRlsTitle)
+		title = "🎉 New Manga Added! " + title
+		color = 0xFFD700
+	}
+
+	return discord.Notification{
+		MangaTitle:    newChapter.MangaTitle,
+		ChapterNumber: newChapter.ChapterNumber,
+		ChapterTitle:  newChapter.ChapterTitle,
+		ReleaseTime:   releaseTime,
+		Title:         title,
+		Description:   desc,
+		URL:           coll.releaseURL(newChapter.ReleaseLink),
+		Footer:        footer,
+		FooterIconURL: coll.footerIconURL(),
+		Author:        discord.ChapterAuthor(coll.cfg),
+		Color:         color,
+		ThumbnailURL:  thumbnail,
+		ImageURL:      bannerURL,
+		Fields:        fields,
+		Silent:        silent,
+	}
+}
+
+// inPingCooldown reports whether the role of the manga was pinged less than mangaPingCooldownMinutes ago.
+func (coll *Collector) inPingCooldown(mangaTitle string, now time.Time) bool {
+	cooldown := time.Duration(coll.cfg.Config.MangaPingCooldownMinutes) * time.Minute
+	if cooldown <= 0 {
+		return false
+	}
+
+	var lastNotifiedAt time.Time
+	coll.chapters.Range(func(_ string, chapter domain.ChapterInfo) bool {
+		if chapter.LastNotifiedAt.After(lastNotifiedAt) && utils.TitleMatches(chapter.MangaTitle, mangaTitle, false) {
+			lastNotifiedAt = chapter.LastNotifiedAt
+		}
+		return true
+	})
+
+	return !lastNotifiedAt.IsZero() && now.Sub(lastNotifiedAt) < cooldown
+}
+
+// footerIconURL returns the icon shown next to the footer of chapter notifications.
+func (coll *Collector) footerIconURL() string {
+	if coll.cfg.Config.DiscordFooterIconURL != "" {
+		return coll.cfg.Config.DiscordFooterIconURL
+	}
+
+	return FooterIconURL
+}
+
+// releaseURL returns the url of a release on the website the running check scraped, or on the first scrape url
+// if it only checked the feed.
+func (coll *Collector) releaseURL(releaseLink string) string {
+	websiteURL := coll.websiteURL
+	if websiteURL == "" {
+		websiteURL = coll.cfg.Config.ScrapeURLs[0]
+	}
+
+	return utils.ReleaseURL(websiteURL, releaseLink)
+}
+
+// waitSinceLastChapter builds an embed field with the time that passed since the previous chapter
+// of the same manga was released. Returns nil if there is no previous chapter.
+func (coll *Collector) waitSinceLastChapter(ctx context.Context, chapter domain.ChapterInfo) *discordgo.MessageEmbedField {
+	previousTime, err := coll.db.GetPreviousChapterTime(ctx, chapter.MangaTitle, chapter.ChapterNumber)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			coll.log.Error().Err(err).Msgf("error getting previous chapter time: %q", chapter.MangaTitle)
+		}
+		return nil
+	}
+
+	currentTime, err := domain.ParseReleaseTime(chapter.ReleaseTime)
+	if err != nil {
+		return nil
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:   "Wait since last chapter",
+		Value:  utils.FormatDuration(currentTime.Sub(previousTime)),
+		Inline: true,
+	}
+}
+
+// fetchThumbnailURL returns the cover image of the chapter card if it can be reached, otherwise an
+// empty string is returned and the notification is sent without a thumbnail.
+func (coll *Collector) fetchThumbnailURL(ctx context.Context, e *colly.HTMLElement) string {
+	src := e.ChildAttr("img", "src")
+	if src == "" {
+		coll.log.Trace().Msg("Couldn't find a thumbnail in the chapter card")
+		return ""
+	}
+	thumbnailURL := e.Request.AbsoluteURL(src)
+
+	client := utils.NewHTTPClient(coll.cfg.Config.ScrapeProxyURL, 3*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, thumbnailURL, nil)
+	if err != nil {
+		coll.log.Debug().Err(err).Msgf("error creating thumbnail request: %q", thumbnailURL)
+		return ""
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		coll.log.Debug().Err(err).Msgf("error checking thumbnail: %q", thumbnailURL)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		coll.log.Debug().Msgf("Thumbnail is not a reachable image: %q", thumbnailURL)
+		return ""
+	}
+
+	return thumbnailURL
+}
+
+// mangaMetadata returns the cached AniList metadata of a manga. If the manga is seen for the first
+// time, the metadata is fetched from AniList and cached.
+func (coll *Collector) mangaMetadata(ctx context.Context, mangaTitle string) (domain.MangaMetadata, bool) {
+	metadata, err := coll.db.GetMangaMetadata(ctx, mangaTitle)
+	if err == nil {
+		return metadata, true
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		coll.log.Error().Err(err).Msgf("error loading manga metadata: %q", mangaTitle)
+		return domain.MangaMetadata{}, false
+	}
+
+	metadata, err = coll.anilist.GetMangaMetadata(ctx, mangaTitle)
+	if err != nil {
+		coll.log.Warn().Err(err).Msgf("error fetching manga metadata from AniList: %q", mangaTitle)
+		return domain.MangaMetadata{}, false
+	}
+
+	if err := coll.db.SaveMangaMetadata(ctx, metadata); err != nil {
+		coll.log.Error().Err(err).Msgf("error saving manga metadata: %q", mangaTitle)
+	}
+
+	return metadata, true
 }
@@ -0,0 +1,137 @@
+package html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/hiatus"
+	"tcb-bot/internal/testutils"
+)
+
+// newTestCollector returns a collector that answers every scrape url with page, stores its chapters in an
+// InMemoryStore and sends its notifications to a MockSession. Only "One Piece" is watched.
+func newTestCollector(t *testing.T, page string, overrides ...func(*domain.Config)) (*FakeCollector,
+	*domain.InMemoryStore, *testutils.MockSession) {
+	t.Helper()
+
+	watched := func(cfg *domain.Config) {
+		cfg.WatchedMangas = []string{"One Piece"}
+	}
+	cfg := testutils.NewTestConfig(append([]func(*domain.Config){watched}, overrides...)...)
+	log := testutils.NewTestLogger(t)
+	store := domain.NewInMemoryStore()
+	bot, session := testutils.NewTestDiscord(t)
+	db := testutils.NewTestDB(t)
+
+	coll := NewFakeCollector(log, cfg, store, bot, db, hiatus.NewChecker(log, cfg, store, bot, db), page)
+
+	return coll, store, session
+}
+
+func TestProcessChapter(t *testing.T) {
+	tests := []struct {
+		name      string
+		collected []domain.CollectedChapter
+		chapter   string
+		overrides []func(*domain.Config)
+		want      bool
+		// releaseTitle is the release title the chapter is stored under, if it differs from the scraped one
+		releaseTitle string
+	}{
+		{
+			name:    "new chapter of a watched manga",
+			chapter: `{"manga_title": "One Piece", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`,
+			want:    true,
+		},
+		{
+			name:    "manga is not watched",
+			chapter: `{"manga_title": "Chainsaw Man", "chapter_number": "150", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7701/chainsaw-man-chapter-150"}`,
+			want:    false,
+		},
+		{
+			name: "chapter was already announced",
+			collected: []domain.CollectedChapter{{
+				ReleaseTitle: "One Piece Chapter 1100",
+				ChapterInfo:  domain.ChapterInfo{MangaTitle: "One Piece", ChapterNumber: "1100", AnnouncedAt: time.Now()},
+			}},
+			chapter: `{"manga_title": "One Piece", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`,
+			want:    false,
+		},
+		{
+			name: "chapter was collected but never announced",
+			collected: []domain.CollectedChapter{{
+				ReleaseTitle: "One Piece Chapter 1100",
+				ChapterInfo:  domain.ChapterInfo{MangaTitle: "One Piece", ChapterNumber: "1100"},
+			}},
+			chapter: `{"manga_title": "One Piece", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`,
+			want:    true,
+		},
+		{
+			name: "chapter of an alias was already announced",
+			collected: []domain.CollectedChapter{{
+				ReleaseTitle: "One Piece Chapter 1100",
+				ChapterInfo:  domain.ChapterInfo{MangaTitle: "One Piece", ChapterNumber: "1100", AnnouncedAt: time.Now()},
+			}},
+			chapter: `{"manga_title": "One Piece (Official)", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`,
+			overrides: []func(*domain.Config){func(cfg *domain.Config) {
+				cfg.MangaAliases = map[string][]string{"one piece": {"One Piece (Official)"}}
+			}},
+			want: false,
+		},
+		{
+			name:    "new chapter of an alias",
+			chapter: `{"manga_title": "One Piece (Official)", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`,
+			overrides: []func(*domain.Config){func(cfg *domain.Config) {
+				cfg.MangaAliases = map[string][]string{"one piece": {"One Piece (Official)"}}
+			}},
+			want:         true,
+			releaseTitle: "One Piece Chapter 1100",
+		},
+		{
+			name:    "chapter is before the start chapter",
+			chapter: `{"manga_title": "One Piece", "chapter_number": "1099", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7699/one-piece-chapter-1099"}`,
+			overrides: []func(*domain.Config){func(cfg *domain.Config) {
+				cfg.MangaStartChapter = map[string]string{"One Piece": "1100"}
+			}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coll, store, session := newTestCollector(t, "", tt.overrides...)
+			for _, collected := range tt.collected {
+				store.Store(collected.ReleaseTitle, collected.ChapterInfo)
+			}
+			coll.mangas = coll.cfg.Config.AllWatchedMangas()
+			coll.suppressed = make(map[string]struct{})
+
+			chapter := testutils.MustParseChapter(t, tt.chapter)
+			if got := coll.processChapter(context.Background(), chapter, nil); got != tt.want {
+				t.Fatalf("processChapter() = %v, want %v", got, tt.want)
+			}
+
+			wantMessages := 0
+			if tt.want {
+				wantMessages = 1
+			}
+			if got := len(session.Messages()); got != wantMessages {
+				t.Fatalf("sent %d messages, want %d", got, wantMessages)
+			}
+
+			releaseTitle := tt.releaseTitle
+			if releaseTitle == "" {
+				releaseTitle = chapter.MangaTitle + " Chapter " + chapter.ChapterNumber
+			}
+			stored, ok := store.Load(releaseTitle)
+			if tt.want && (!ok || stored.AnnouncedAt.IsZero()) {
+				t.Errorf("%q is not marked as announced", releaseTitle)
+			}
+			if !tt.want && len(tt.collected) == 0 && ok {
+				t.Errorf("%q was collected, it must not be", releaseTitle)
+			}
+		})
+	}
+}
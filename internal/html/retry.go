@@ -0,0 +1,137 @@
+package html
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+)
+
+const (
+	// maxNotificationAttempts is the number of retries after which a failed notification is given up on
+	maxNotificationAttempts = 5
+
+	// maxRetryBackoff caps the exponential back-off between two retries
+	maxRetryBackoff = time.Hour
+)
+
+// retryBackoff returns how long to wait before retrying a notification that was already retried attempts times,
+// starting at a minute and doubling with every attempt.
+func retryBackoff(attempts int) time.Duration {
+	backoff := time.Minute << attempts
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return backoff
+}
+
+// queueRetry queues the notification of a chapter that wasn't sent, it's retried by RetryNotifications at
+// nextAttemptAt. Only the notifiers that failed to send it retry it, all of them if notifiers is empty.
+func (coll *Collector) queueRetry(ctx context.Context, cleanRlsTitle string, nextAttemptAt time.Time,
+	notifiers []string) {
+	if err := coll.db.QueueNotification(ctx, cleanRlsTitle, nextAttemptAt, notifiers); err != nil {
+		coll.log.Error().Err(err).Msgf("error queueing notification: %q", cleanRlsTitle)
+	}
+}
+
+// RetryNotifications sends the queued notifications that are due. Notifications that still fail are retried with
+// an exponential back-off, after maxNotificationAttempts retries they're dropped and an error notification is sent.
+func (coll *Collector) RetryNotifications(ctx context.Context) error {
+	// checks and retries both update the chapters, so they never run at the same time
+	coll.m.Lock()
+	defer coll.m.Unlock()
+
+	now := time.Now()
+	queued, err := coll.db.DueNotifications(ctx, now)
+	if err != nil {
+		return fmt.Errorf("could not load queued notifications: %w", err)
+	}
+
+	for _, notification := range queued {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		coll.retryNotification(ctx, notification, now)
+	}
+
+	return nil
+}
+
+func (coll *Collector) retryNotification(ctx context.Context, queued domain.QueuedNotification, now time.Time) {
+	chapter, ok := coll.chapters.Load(queued.ReleaseTitle)
+	if !ok {
+		coll.log.Debug().Msgf("Chapter doesn't exist anymore, dropping queued notification: %q", queued.ReleaseTitle)
+		coll.deleteQueued(ctx, queued)
+		return
+	}
+
+	silent := coll.inPingCooldown(chapter.MangaTitle, now)
+	// the chapter card isn't available anymore, so configured covers and AniList are the only thumbnails
+	notification := coll.newNotification(ctx, queued.ReleaseTitle, chapter, nil, silent, false)
+	notification.Notifiers = queued.Notifiers
+
+	coll.log.Debug().Msgf("Retrying notification, attempt %d of %d: %q", queued.AttemptCount+1,
+		maxNotificationAttempts, queued.ReleaseTitle)
+	err := coll.notifier.SendNotification(ctx, notification)
+
+	// held back notifications didn't fail, they are sent once the quiet hours end
+	var held *discord.HeldError
+	if errors.As(err, &held) {
+		coll.log.Debug().Msgf("Quiet hours are active, holding back notification until %s: %q",
+			held.Until.Format(time.RFC1123), queued.ReleaseTitle)
+		if err := coll.db.RescheduleNotification(ctx, queued.ID, queued.AttemptCount, held.Until,
+			queued.Notifiers); err != nil {
+			coll.log.Error().Err(err).Msgf("error rescheduling notification: %q", queued.ReleaseTitle)
+		}
+		return
+	}
+
+	if err != nil {
+		attempts := queued.AttemptCount + 1
+		if attempts >= maxNotificationAttempts {
+			coll.log.Error().Err(err).Msgf("error sending notification, giving up after %d attempts: %q", attempts,
+				queued.ReleaseTitle)
+			coll.notifier.SendErrorNotification(ctx, "Notification failed", fmt.Sprintf(
+				"Could not announce %s after %d retries, giving up: %v", queued.ReleaseTitle, attempts, err), "")
+			coll.deleteQueued(ctx, queued)
+			return
+		}
+
+		// notifiers that sent it by now don't send it again
+		notifiers := queued.Notifiers
+		if failed := discord.FailedNotifiers(err); failed != nil {
+			notifiers = failed
+		}
+
+		nextAttemptAt := now.Add(retryBackoff(attempts))
+		coll.log.Warn().Err(err).Msgf("error sending notification, retrying at %s: %q",
+			nextAttemptAt.Format(time.RFC1123), queued.ReleaseTitle)
+		if err := coll.db.RescheduleNotification(ctx, queued.ID, attempts, nextAttemptAt, notifiers); err != nil {
+			coll.log.Error().Err(err).Msgf("error rescheduling notification: %q", queued.ReleaseTitle)
+		}
+		return
+	}
+
+	coll.log.Info().Msgf("Sent queued notification for: %q", queued.ReleaseTitle)
+	coll.deleteQueued(ctx, queued)
+	coll.chapterFound(chapter)
+
+	chapter.AnnouncedAt = now
+	if !silent {
+		chapter.LastNotifiedAt = now
+	}
+	coll.chapters.Store(queued.ReleaseTitle, chapter)
+	if err := coll.db.SaveCollectedChapter(ctx, queued.ReleaseTitle, chapter); err != nil {
+		coll.log.Error().Err(err).Msgf("error saving announced chapter: %q", queued.ReleaseTitle)
+	}
+}
+
+func (coll *Collector) deleteQueued(ctx context.Context, queued domain.QueuedNotification) {
+	if err := coll.db.DeleteQueuedNotification(ctx, queued.ID); err != nil {
+		coll.log.Error().Err(err).Msgf("error removing queued notification: %q", queued.ReleaseTitle)
+	}
+}
@@ -0,0 +1,108 @@
+package html
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/hiatus"
+	"tcb-bot/internal/testutils"
+)
+
+// fakeNotifier counts the chapter notifications it sent, it fails to send them while fail is set.
+type fakeNotifier struct {
+	fail     bool
+	sent     int
+	attempts int
+}
+
+func (n *fakeNotifier) SendNotification(context.Context, discord.Notification) error {
+	n.attempts++
+	if n.fail {
+		return errors.New("send failed")
+	}
+
+	n.sent++
+	return nil
+}
+
+func (n *fakeNotifier) SendWarnNotification(context.Context, string, string)             {}
+func (n *fakeNotifier) SendErrorNotification(context.Context, string, string, string)    {}
+func (n *fakeNotifier) SendCriticalNotification(context.Context, string, string)         {}
+func (n *fakeNotifier) SendResolvedNotification(context.Context, string, string, string) {}
+func (n *fakeNotifier) SendHiatusNotification(context.Context, string, string, int)      {}
+
+// TestRetryFailedNotifier sends a chapter to two notifiers of which one fails. Only the failed one may retry it.
+func TestRetryFailedNotifier(t *testing.T) {
+	cfg := testutils.NewTestConfig(func(cfg *domain.Config) {
+		cfg.WatchedMangas = []string{"One Piece"}
+	})
+	log := testutils.NewTestLogger(t)
+	store := domain.NewInMemoryStore()
+	db := testutils.NewTestDB(t)
+
+	discordNotifier, telegramNotifier := &fakeNotifier{}, &fakeNotifier{fail: true}
+	notifier := discord.MultiNotifier{
+		{Name: "discord", Notifier: discordNotifier},
+		{Name: "telegram", Notifier: telegramNotifier},
+	}
+	coll := NewFakeCollector(log, cfg, store, notifier, db, hiatus.NewChecker(log, cfg, store, notifier, db), "")
+	coll.mangas = cfg.Config.AllWatchedMangas()
+	coll.suppressed = make(map[string]struct{})
+
+	ctx := context.Background()
+	chapter := testutils.MustParseChapter(t,
+		`{"manga_title": "One Piece", "chapter_number": "1100", "release_time": "2024-01-05T12:00:00Z", "release_link": "/chapters/7700/one-piece-chapter-1100"}`)
+	if !coll.processChapter(ctx, chapter, nil) {
+		t.Fatal("processChapter() = false, want the chapter to be announced")
+	}
+
+	// dueNotifications returns the queued notifications once their back-off has passed
+	dueNotifications := func() []domain.QueuedNotification {
+		t.Helper()
+
+		queued, err := db.DueNotifications(ctx, time.Now().Add(maxRetryBackoff))
+		if err != nil {
+			t.Fatalf("DueNotifications() returned an error: %v", err)
+		}
+		return queued
+	}
+
+	retries := []struct {
+		name string
+		// fail makes the telegram notifier fail the retry
+		fail       bool
+		wantQueued bool
+	}{
+		{name: "telegram still fails", fail: true, wantQueued: true},
+		{name: "telegram succeeds"},
+	}
+	for _, retry := range retries {
+		queued := dueNotifications()
+		if len(queued) != 1 || !slices.Equal(queued[0].Notifiers, []string{"telegram"}) {
+			t.Fatalf("%s: queued notifications are %+v, want one for telegram", retry.name, queued)
+		}
+
+		telegramNotifier.fail = retry.fail
+		coll.retryNotification(ctx, queued[0], time.Now())
+
+		if queued := dueNotifications(); (len(queued) == 1) != retry.wantQueued {
+			t.Errorf("%s: queued notifications are %+v, want queued %v", retry.name, queued, retry.wantQueued)
+		}
+	}
+
+	if discordNotifier.attempts != 1 {
+		t.Errorf("discord sent the notification %d times, want it to be sent once", discordNotifier.attempts)
+	}
+	if telegramNotifier.attempts != 3 || telegramNotifier.sent != 1 {
+		t.Errorf("telegram sent the notification %d times in %d attempts, want 1 in 3", telegramNotifier.sent,
+			telegramNotifier.attempts)
+	}
+	if stored, ok := store.Load("One Piece Chapter 1100"); !ok || stored.AnnouncedAt.IsZero() {
+		t.Error("chapter is not marked as announced")
+	}
+}
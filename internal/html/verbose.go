@@ -0,0 +1,32 @@
+package html
+
+import (
+	"github.com/gocolly/colly"
+)
+
+// registerVerboseCallbacks logs every request and response of the scraper and the raw HTML of every chapter card,
+// so it's possible to see what the site returned when a chapter isn't detected.
+func (coll *Collector) registerVerboseCallbacks() {
+	coll.cl.OnRequest(func(r *colly.Request) {
+		coll.log.Debug().Str("url", r.URL.String()).Str("user_agent", r.Headers.Get("User-Agent")).Msg("Sending request")
+	})
+
+	coll.cl.OnResponse(func(r *colly.Response) {
+		coll.log.Debug().Str("url", r.Request.URL.String()).Int("status", r.StatusCode).Int("body_size", len(r.Body)).
+			Msg("Received response")
+	})
+
+	coll.cl.OnError(func(r *colly.Response, err error) {
+		coll.log.Debug().Err(err).Str("url", r.Request.URL.String()).Int("status", r.StatusCode).
+			Int("body_size", len(r.Body)).Msg("Received error response")
+	})
+
+	coll.cl.OnHTML(ChapterCardSelector, func(e *colly.HTMLElement) {
+		html, err := e.DOM.Html()
+		if err != nil {
+			coll.log.Debug().Err(err).Msg("error rendering chapter card")
+			return
+		}
+		coll.log.Debug().Str("html", html).Msg("Found chapter card")
+	})
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// dailyFileFormat is appended to the name of daily log files, e.g. tcb-bot-2006-01-02.log
+const dailyFileFormat = "2006-01-02"
+
+// dailyWriter writes to a new log file every day, the date is appended to the name of the log file. Only the files
+// of the last maxBackups days before the current one are kept, all of them if maxBackups is 0.
+type dailyWriter struct {
+	m          sync.Mutex
+	path       string
+	maxSize    int
+	maxBackups int
+	now        func() time.Time
+
+	day     string
+	current *lumberjack.Logger
+}
+
+func newDailyWriter(path string, maxSize, maxBackups int) *dailyWriter {
+	return &dailyWriter{path: path, maxSize: maxSize, maxBackups: maxBackups, now: time.Now}
+}
+
+func (w *dailyWriter) Write(p []byte) (int, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if day := w.now().Format(dailyFileFormat); day != w.day {
+		if w.current != nil {
+			// the file of the previous day is complete, a failed close must not lose the new logs
+			_ = w.current.Close()
+		}
+
+		w.day = day
+		w.current = &lumberjack.Logger{
+			Filename:   datedPath(w.path, day),
+			MaxSize:    w.maxSize, // megabytes
+			MaxAge:     1,         // days
+			MaxBackups: w.maxBackups,
+		}
+
+		// lumberjack only removes the backups of the current file, the files of earlier days are pruned here
+		w.pruneDays()
+	}
+
+	return w.current.Write(p)
+}
+
+// pruneDays removes the log files of all but the last maxBackups days before the current one, including the files
+// lumberjack rotated them into. Files that can't be removed are left for the next day.
+func (w *dailyWriter) pruneDays() {
+	if w.maxBackups <= 0 {
+		return
+	}
+
+	dir := filepath.Dir(w.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	// the files of a day are its dated file and their backups, e.g. tcb-bot-2006-01-02-2006-01-02T15-04-05.000.log
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+	filesByDay := make(map[string][]string)
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() || !strings.HasSuffix(name, ext) || len(name) < len(dailyFileFormat) {
+			continue
+		}
+
+		day := name[:len(dailyFileFormat)]
+		if _, err := time.Parse(dailyFileFormat, day); err != nil || day >= w.day {
+			continue
+		}
+		filesByDay[day] = append(filesByDay[day], filepath.Join(dir, entry.Name()))
+	}
+
+	days := make([]string, 0, len(filesByDay))
+	for day := range filesByDay {
+		days = append(days, day)
+	}
+	slices.Sort(days)
+
+	for _, day := range days[:max(len(days)-w.maxBackups, 0)] {
+		for _, file := range filesByDay[day] {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+// datedPath inserts the day before the extension of path, e.g. logs/tcb-bot.log becomes logs/tcb-bot-2006-01-02.log.
+func datedPath(path, day string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + day + ext
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDailyWriterPrunesDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBackups int
+		want       []string
+	}{
+		{
+			name:       "keeps the last maxBackups days",
+			maxBackups: 2,
+			want: []string{
+				"other.log",
+				"tcb-bot-2024-01-04.log",
+				"tcb-bot-2024-01-05-2024-01-05T10-00-00.000.log",
+				"tcb-bot-2024-01-05.log",
+				"tcb-bot-2024-01-06.log",
+				"tcb-bot.log",
+			},
+		},
+		{
+			name:       "keeps everything without maxBackups",
+			maxBackups: 0,
+			want: []string{
+				"other.log",
+				"tcb-bot-2024-01-01.log",
+				"tcb-bot-2024-01-02-2024-01-02T10-00-00.000.log",
+				"tcb-bot-2024-01-02.log",
+				"tcb-bot-2024-01-03.log",
+				"tcb-bot-2024-01-04.log",
+				"tcb-bot-2024-01-05-2024-01-05T10-00-00.000.log",
+				"tcb-bot-2024-01-05.log",
+				"tcb-bot-2024-01-06.log",
+				"tcb-bot.log",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{
+				"tcb-bot-2024-01-01.log",
+				"tcb-bot-2024-01-02.log",
+				"tcb-bot-2024-01-02-2024-01-02T10-00-00.000.log",
+				"tcb-bot-2024-01-03.log",
+				"tcb-bot-2024-01-04.log",
+				"tcb-bot-2024-01-05.log",
+				"tcb-bot-2024-01-05-2024-01-05T10-00-00.000.log",
+				// files that aren't daily log files are never removed
+				"tcb-bot.log",
+				"other.log",
+			} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("log\n"), 0o644); err != nil {
+					t.Fatalf("could not create %s: %v", name, err)
+				}
+			}
+
+			w := newDailyWriter(filepath.Join(dir, "tcb-bot.log"), 1, tt.maxBackups)
+			w.now = func() time.Time { return time.Date(2024, 1, 6, 0, 0, 1, 0, time.UTC) }
+			t.Cleanup(func() { w.current.Close() })
+
+			if _, err := w.Write([]byte("log\n")); err != nil {
+				t.Fatalf("Write() returned an error: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("could not read log dir: %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("log files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,10 +7,12 @@ package logger
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"tcb-bot/internal/domain"
 
+	"github.com/autobrr/autobrr/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -37,17 +39,18 @@ type DefaultLogger struct {
 	writers []io.Writer
 }
 
-func New(cfg *domain.Config) Logger {
+// New creates the logger configured by cfg. It returns an error if the directory of the log file can't be created.
+func New(cfg *domain.Config) (Logger, error) {
 	l := &DefaultLogger{
 		writers: make([]io.Writer, 0),
 		level:   zerolog.DebugLevel,
 	}
 
 	// set log level
-	l.SetLogLevel(cfg.LogLevel)
+	l.SetLogLevel(cfg.EffectiveLogLevel())
 
-	// use pretty logging for dev only
-	if cfg.Version == "dev" {
+	// the log file is always JSON, only stderr can be human-readable
+	if cfg.LogFormat == domain.LogFormatConsole {
 		// setup console writer
 		consoleWriter := zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}
 
@@ -58,6 +61,14 @@ func New(cfg *domain.Config) Logger {
 	}
 
 	if cfg.LogPath != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.LogPath), 0o755); err != nil {
+			return nil, errors.Wrap(err, "could not create log directory of %s", cfg.LogPath)
+		}
+	}
+
+	if cfg.LogPath != "" && cfg.LogRotateDaily {
+		l.writers = append(l.writers, newDailyWriter(cfg.LogPath, cfg.LogMaxSize, cfg.LogMaxBackups))
+	} else if cfg.LogPath != "" {
 		l.writers = append(l.writers,
 			&lumberjack.Logger{
 				Filename:   cfg.LogPath,
@@ -67,14 +78,21 @@ func New(cfg *domain.Config) Logger {
 		)
 	}
 
+	if cfg.SentryDSN != "" {
+		l.writers = append(l.writers, sentryWriter{})
+	}
+
+	// streamed to the clients of the /logs endpoint
+	l.writers = append(l.writers, stream)
+
 	// set some defaults
 	zerolog.TimeFieldFormat = time.RFC3339
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
 	// init new logger
-	l.log = zerolog.New(io.MultiWriter(l.writers...)).With().Stack().Logger()
+	l.log = zerolog.New(zerolog.MultiLevelWriter(l.writers...)).With().Stack().Logger()
 
-	return l
+	return l, nil
 }
 
 func (l *DefaultLogger) SetLogLevel(level string) {
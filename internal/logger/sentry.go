@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+)
+
+// sentryFlushTimeout is how long fatal errors may take to reach Sentry before the bot exits
+const sentryFlushTimeout = 2 * time.Second
+
+// sentryWriter reports every error and fatal log line to Sentry. Nothing is sent until sentry.Init was called.
+type sentryWriter struct{}
+
+func (w sentryWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w sentryWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level != zerolog.ErrorLevel && level != zerolog.FatalLevel {
+		return len(p), nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return len(p), nil
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	if level == zerolog.FatalLevel {
+		event.Level = sentry.LevelFatal
+	}
+
+	message, _ := fields[zerolog.MessageFieldName].(string)
+	event.Message = message
+	if err, ok := fields[zerolog.ErrorFieldName].(string); ok {
+		event.Exception = []sentry.Exception{{Type: message, Value: err}}
+	}
+	if module, ok := fields["module"].(string); ok {
+		event.Tags["module"] = module
+	}
+	for key, value := range fields {
+		switch key {
+		case zerolog.MessageFieldName, zerolog.ErrorFieldName, zerolog.LevelFieldName, zerolog.TimestampFieldName, "module":
+		default:
+			event.Extra[key] = value
+		}
+	}
+
+	sentry.CaptureEvent(event)
+
+	// zerolog exits right after logging fatal errors
+	if level == zerolog.FatalLevel {
+		sentry.Flush(sentryFlushTimeout)
+	}
+
+	return len(p), nil
+}
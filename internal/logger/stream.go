@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+const (
+	// maxLogSubscribers limits how many clients can stream the logs at the same time
+	maxLogSubscribers = 10
+
+	// logSubscriberBuffer is how many lines a slow subscriber may fall behind before lines are dropped for it
+	logSubscriberBuffer = 256
+)
+
+// ErrTooManySubscribers is returned by SubscribeLogs if maxLogSubscribers are already streaming the logs.
+var ErrTooManySubscribers = errors.New("too many log subscribers")
+
+// stream is written to by every logger, it's a no-op while nobody is subscribed.
+var stream = &logStream{subscribers: make(map[chan []byte]struct{})}
+
+// logStream broadcasts every JSON log line to all subscribers. Lines are dropped for subscribers that can't keep
+// up, so a slow client never blocks logging.
+type logStream struct {
+	m           sync.Mutex
+	subscribers map[chan []byte]struct{}
+}
+
+func (s *logStream) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(s.subscribers) == 0 {
+		return len(p), nil
+	}
+
+	// zerolog reuses the buffer after the write returns
+	line := bytes.TrimSpace(bytes.Clone(p))
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- line:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// SubscribeLogs returns a channel that receives every log line from now on. The returned function unsubscribes
+// again and must be called once the subscriber is done.
+func SubscribeLogs() (<-chan []byte, func(), error) {
+	stream.m.Lock()
+	defer stream.m.Unlock()
+
+	if len(stream.subscribers) >= maxLogSubscribers {
+		return nil, nil, ErrTooManySubscribers
+	}
+
+	subscriber := make(chan []byte, logSubscriberBuffer)
+	stream.subscribers[subscriber] = struct{}{}
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			stream.m.Lock()
+			delete(stream.subscribers, subscriber)
+			stream.m.Unlock()
+		})
+	}, nil
+}
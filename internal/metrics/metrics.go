@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"tcb-bot/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	scrapeTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tcb_bot_scrape_total",
+		Help: "Total number of scrapes, labelled by status.",
+	}, []string{"status"})
+
+	scrapeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tcb_bot_scrape_duration_seconds",
+		Help:    "Duration of scrapes in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	notificationsSentTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tcb_bot_notifications_sent_total",
+		Help: "Total number of chapter notifications sent, labelled by manga.",
+	}, []string{"manga"})
+
+	discordErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tcb_bot_discord_errors_total",
+		Help: "Total number of errors while sending to Discord.",
+	})
+
+	chaptersCollected = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tcb_bot_chapters_collected_total",
+		Help: "Number of collected chapters.",
+	})
+)
+
+// Handler serves all metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveScrape records the result and duration of a scrape.
+func ObserveScrape(duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+
+	scrapeTotal.WithLabelValues(status).Inc()
+	scrapeDuration.Observe(duration.Seconds())
+}
+
+// NotificationSent counts the notification of an announced chapter, it's meant to be used as the chapter found hook
+// of the collector.
+func NotificationSent(chapter domain.ChapterInfo) {
+	notificationsSentTotal.WithLabelValues(chapter.MangaTitle).Inc()
+}
+
+func DiscordError() {
+	discordErrorsTotal.Inc()
+}
+
+func SetChaptersCollected(count int) {
+	chaptersCollected.Set(float64(count))
+}
@@ -0,0 +1,77 @@
+package otel
+
+import (
+	"context"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/logger"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "tcb-bot"
+
+// Span attributes shared by the scrape, database and Discord spans.
+const (
+	MangaTitleKey       = attribute.Key("manga.title")
+	ChapterNumberKey    = attribute.Key("chapter.number")
+	DiscordChannelIDKey = attribute.Key("discord.channel_id")
+)
+
+// Init exports traces to the OTLP/HTTP endpoint at otelEndpoint. Without an endpoint, the global tracer stays a
+// no-op. The returned function flushes pending spans and has to be called on shutdown.
+func Init(ctx context.Context, log logger.Logger, cfg *config.AppConfig) (func(context.Context) error, error) {
+	if cfg.Config.OtelEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Config.OtelEndpoint))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create OTLP exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.Config.OtelServiceName),
+		semconv.ServiceVersion(cfg.Config.Version),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create OpenTelemetry resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	log.Info().Msgf("Exporting traces to %s", cfg.Config.OtelEndpoint)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of tcb-bot, it's a no-op unless Init was called with an endpoint.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// ChapterAttributes returns the attributes identifying a chapter.
+func ChapterAttributes(mangaTitle string, chapterNumber string) []attribute.KeyValue {
+	return []attribute.KeyValue{MangaTitleKey.String(mangaTitle), ChapterNumberKey.String(chapterNumber)}
+}
+
+// EndSpan marks the span as failed if err isn't nil and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
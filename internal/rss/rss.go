@@ -0,0 +1,87 @@
+package rss
+
+import (
+	"context"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/utils"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/mmcdole/gofeed"
+	"github.com/rs/zerolog"
+)
+
+// ChapterHandler is called for every chapter found in the feed.
+type ChapterHandler func(ctx context.Context, chapter domain.ChapterInfo)
+
+// Collector checks the configured RSS or Atom feed for new chapter releases.
+type Collector struct {
+	log    zerolog.Logger
+	cfg    *config.AppConfig
+	parser *gofeed.Parser
+	handle ChapterHandler
+}
+
+func NewCollector(log logger.Logger, cfg *config.AppConfig, handle ChapterHandler) *Collector {
+	parser := gofeed.NewParser()
+	parser.Client = utils.NewHTTPClient(cfg.Config.ScrapeProxyURL,
+		time.Duration(cfg.Config.ScrapeTimeoutSeconds)*time.Second)
+
+	return &Collector{
+		log:    log.With().Str("module", "rss").Logger(),
+		cfg:    cfg,
+		parser: parser,
+		handle: handle,
+	}
+}
+
+func (c *Collector) Run(ctx context.Context) error {
+	c.log.Trace().Msgf("Checking feed for new releases: %s", c.cfg.Config.RSSFeedURL)
+	feed, err := c.parser.ParseURLWithContext(c.cfg.Config.RSSFeedURL, ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not parse feed: %s", c.cfg.Config.RSSFeedURL)
+	}
+
+	for _, item := range feed.Items {
+		chapter, err := ParseItem(item)
+		if err != nil {
+			c.log.Error().Err(err).Msgf("error parsing feed item: %q", item.Title)
+			continue
+		}
+
+		c.handle(ctx, chapter)
+	}
+
+	return nil
+}
+
+// ParseItem maps a feed item to a chapter. The title of the item must be a release title like
+// "One Piece Chapter 1100" and the release time is taken from its published or updated date.
+func ParseItem(item *gofeed.Item) (domain.ChapterInfo, error) {
+	mangaTitle, _, chapterNumber, err := utils.ParseReleaseTitle(item.Title)
+	if err != nil {
+		return domain.ChapterInfo{}, err
+	}
+
+	if item.Link == "" {
+		return domain.ChapterInfo{}, errors.New("item has no link: %q", item.Title)
+	}
+
+	released := item.PublishedParsed
+	if released == nil {
+		released = item.UpdatedParsed
+	}
+	if released == nil {
+		return domain.ChapterInfo{}, errors.New("item has no release time: %q", item.Title)
+	}
+
+	return domain.ChapterInfo{
+		ReleaseLink:   item.Link,
+		MangaTitle:    mangaTitle,
+		ChapterNumber: chapterNumber,
+		ReleaseTime:   domain.FormatReleaseTime(*released),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/feed"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/metrics"
+	"tcb-bot/internal/state"
+
+	sentryhttp "github.com/getsentry/sentry-go/http"
+	"github.com/rs/zerolog"
+)
+
+type Server struct {
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	chapters domain.ChapterStore
+	bot      *discord.Bot
+	db       *database.DB
+	mux      *http.ServeMux
+	server   *http.Server
+}
+
+func NewServer(log logger.Logger, cfg *config.AppConfig, chapters domain.ChapterStore, bot *discord.Bot,
+	db *database.DB) *Server {
+	return &Server{
+		log:      log.With().Str("module", "http").Logger(),
+		cfg:      cfg,
+		chapters: chapters,
+		bot:      bot,
+		db:       db,
+		mux:      http.NewServeMux(),
+	}
+}
+
+// Mux returns the mux of the server, so other packages can register their routes.
+func (s *Server) Mux() *http.ServeMux {
+	return s.mux
+}
+
+func (s *Server) Open() error {
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /feed.xml", feed.Handler(s.cfg, s.chapters))
+	s.mux.Handle("GET /metrics", metrics.Handler())
+
+	addr := fmt.Sprintf(":%d", s.cfg.Config.HealthCheckPort)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	var handler http.Handler = s.mux
+	if s.cfg.Config.SentryDSN != "" {
+		// panics are reported to Sentry and passed on to the http server like before
+		handler = sentryhttp.New(sentryhttp.Options{Repanic: true}).Handle(handler)
+	}
+
+	s.server = &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error().Err(err).Msg("error serving http requests")
+		}
+	}()
+
+	s.log.Info().Msgf("HTTP server listening on %s", addr)
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.server != nil {
+		return s.server.Shutdown(ctx)
+	}
+	return nil
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	body := map[string]any{
+		"status":         "ok",
+		"uptime_seconds": int(time.Since(state.StartTime()).Seconds()),
+		"circuit":        state.CircuitState(),
+	}
+
+	// the bot is nil if notifications are sent using a webhook
+	if s.bot != nil && !s.bot.IsOpen() {
+		status = http.StatusServiceUnavailable
+		body["status"] = "unavailable"
+		body["reason"] = "discord session is not open"
+	} else if err := s.db.Ping(r.Context()); err != nil {
+		status = http.StatusServiceUnavailable
+		body["status"] = "unavailable"
+		body["reason"] = fmt.Sprintf("database is not open: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Error().Err(err).Msg("error encoding health check response")
+	}
+}
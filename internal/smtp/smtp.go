@@ -0,0 +1,245 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	gosmtp "net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/state"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// implicitTLSPort is the port of SMTP over TLS, every other port uses STARTTLS if the server supports it
+	implicitTLSPort = 465
+
+	// emailsPerMinute keeps mail servers from treating tcb-bot as a spammer
+	emailsPerMinute = 5
+
+	dialTimeout = 10 * time.Second
+)
+
+var chapterTemplate = template.Must(template.New("chapter").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<h2>{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h2>
+{{if .ImageURL}}<img src="{{.ImageURL}}" alt="" style="max-width: 100%;">{{end}}
+<p style="white-space: pre-line;">{{.Description}}</p>
+{{range .Fields}}<p><b>{{.Name}}:</b> {{.Value}}</p>
+{{end}}{{if .URL}}<p><a href="{{.URL}}">Read chapter</a></p>
+{{end}}{{if .Footer}}<p><small>{{.Footer}}</small></p>
+{{end}}</body>
+</html>
+`))
+
+// SMTPNotifier sends notifications as emails, chapters as HTML and everything else as plain text.
+type SMTPNotifier struct {
+	log     zerolog.Logger
+	cfg     *config.AppConfig
+	limiter *discord.RateLimiter
+}
+
+func NewSMTPNotifier(log logger.Logger, cfg *config.AppConfig) *SMTPNotifier {
+	return &SMTPNotifier{
+		log:     log.With().Str("module", "smtp").Logger(),
+		cfg:     cfg,
+		limiter: discord.NewRateLimiter(emailsPerMinute, time.Minute),
+	}
+}
+
+func (s *SMTPNotifier) SendNotification(ctx context.Context, notification discord.Notification) error {
+	var body bytes.Buffer
+	if err := chapterTemplate.Execute(&body, notification); err != nil {
+		return errors.Wrap(err, "could not render email notification")
+	}
+
+	subject := fmt.Sprintf("[tcb-bot] %s Chapter %s", notification.MangaTitle, notification.ChapterNumber)
+	if err := s.send(ctx, subject, "text/html", body.String()); err != nil {
+		return errors.Wrap(err, "could not send email notification")
+	}
+
+	state.NotificationSent()
+	return nil
+}
+
+func (s *SMTPNotifier) SendWarnNotification(ctx context.Context, title string, description string) {
+	s.sendText(ctx, "Warning: "+title, description)
+}
+
+func (s *SMTPNotifier) SendErrorNotification(ctx context.Context, title string, description string, details string) {
+	s.sendText(ctx, "Error: "+title, withDetails(description, details))
+}
+
+func (s *SMTPNotifier) SendCriticalNotification(ctx context.Context, title string, description string) {
+	s.sendText(ctx, "Critical: "+title, description)
+}
+
+func (s *SMTPNotifier) SendResolvedNotification(ctx context.Context, title string, description string, details string) {
+	s.sendText(ctx, "Resolved: "+title, withDetails(description, details))
+}
+
+func (s *SMTPNotifier) SendHiatusNotification(ctx context.Context, title string, description string, _ int) {
+	s.sendText(ctx, title, description)
+}
+
+// withDetails appends the details of an error or resolved notification to its description.
+func withDetails(description string, details string) string {
+	if details == "" {
+		return description
+	}
+
+	return description + "\n\nDetails: " + details
+}
+
+func (s *SMTPNotifier) sendText(ctx context.Context, title string, description string) {
+	if err := s.send(ctx, "[tcb-bot] "+title, "text/plain", description); err != nil {
+		s.log.Error().Err(err).Msgf("Error sending email: %q", title)
+	}
+}
+
+func (s *SMTPNotifier) send(ctx context.Context, subject string, contentType string, body string) error {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	cfg := s.cfg.Config.SMTP
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return errors.Wrap(err, "invalid from address: %q", cfg.From)
+	}
+
+	to := make([]string, 0, len(cfg.To))
+	for _, recipient := range cfg.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return errors.Wrap(err, "invalid to address: %q", recipient)
+		}
+		to = append(to, address.Address)
+	}
+
+	message, err := newMessage(cfg.From, cfg.To, subject, contentType, body)
+	if err != nil {
+		return errors.Wrap(err, "could not create email")
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if cfg.Username != "" {
+		if err := client.Auth(gosmtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return errors.Wrap(err, "could not authenticate with the mail server")
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return errors.Wrap(err, "mail server rejected the sender")
+	}
+	for _, address := range to {
+		if err := client.Rcpt(address); err != nil {
+			return errors.Wrap(err, "mail server rejected the recipient: %q", address)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "could not start sending the email")
+	}
+	if _, err := w.Write(message); err != nil {
+		return errors.Wrap(err, "could not send the email")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "could not send the email")
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the mail server, using implicit TLS on port 465 and STARTTLS on other ports if the server
+// supports it.
+func (s *SMTPNotifier) dial(ctx context.Context) (*gosmtp.Client, error) {
+	cfg := s.cfg.Config.SMTP
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if cfg.Port == implicitTLSPort {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to the mail server: %s", addr)
+	}
+
+	// net/smtp doesn't support contexts, so the whole conversation has to finish in time
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout * 3)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := gosmtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not greet the mail server: %s", addr)
+	}
+
+	if cfg.Port != implicitTLSPort {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, errors.Wrap(err, "could not start TLS with the mail server: %s", addr)
+			}
+		}
+	}
+
+	return client, nil
+}
+
+// newMessage builds an email with a quoted-printable body, so long lines and non-ASCII characters survive.
+func newMessage(from string, to []string, subject string, contentType string, body string) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: " + contentType + "; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+// Package state records runtime information of the bot that is shared between modules.
+package state
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var (
+	startTime = time.Now()
+
+	// lastScrapeTime is stored as unix nanoseconds, zero if there wasn't a successful scrape yet
+	lastScrapeTime         atomic.Int64
+	totalNotificationsSent atomic.Int64
+	circuitState           atomic.Value
+)
+
+// StartTime returns the time the bot was started.
+func StartTime() time.Time {
+	return startTime
+}
+
+// LastScrapeTime returns the time of the last successful scrape or the zero time if there was none.
+func LastScrapeTime() time.Time {
+	nanos := lastScrapeTime.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+func SetLastScrapeTime(t time.Time) {
+	lastScrapeTime.Store(t.UnixNano())
+}
+
+func TotalNotificationsSent() int64 {
+	return totalNotificationsSent.Load()
+}
+
+func NotificationSent() {
+	totalNotificationsSent.Add(1)
+}
+
+// CircuitState returns the state of the scrape circuit breaker.
+func CircuitState() string {
+	if s, ok := circuitState.Load().(string); ok {
+		return s
+	}
+
+	return "closed"
+}
+
+func SetCircuitState(s string) {
+	circuitState.Store(s)
+}
@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/logger"
+	"tcb-bot/internal/state"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const apiURL = "https://api.telegram.org"
+
+// markdownReplacer escapes every character that's reserved in MarkdownV2.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// TelegramNotifier sends notifications to Telegram chats using the Telegram Bot API.
+type TelegramNotifier struct {
+	log    zerolog.Logger
+	cfg    *config.AppConfig
+	client *http.Client
+}
+
+func NewTelegramNotifier(log logger.Logger, cfg *config.AppConfig) *TelegramNotifier {
+	return &TelegramNotifier{
+		log:    log.With().Str("module", "telegram").Logger(),
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *TelegramNotifier) SendNotification(ctx context.Context, notification discord.Notification) error {
+	if err := t.send(ctx, t.cfg.Config.TelegramChatID, formatMessage(notification)); err != nil {
+		return errors.Wrap(err, "could not send Telegram notification")
+	}
+
+	// the Discord notifier already counts notifications that are sent to both
+	if t.cfg.Config.Notifier == "telegram" {
+		state.NotificationSent()
+	}
+	return nil
+}
+
+func (t *TelegramNotifier) SendWarnNotification(ctx context.Context, title string, description string) {
+	t.sendError(ctx, discord.Notification{Title: "⚠️ " + title, Description: description})
+}
+
+func (t *TelegramNotifier) SendErrorNotification(ctx context.Context, title string, description string,
+	details string) {
+	t.sendError(ctx, discord.Notification{Title: "❌ " + title, Description: description,
+		Fields: discord.DetailsFields(details)})
+}
+
+func (t *TelegramNotifier) SendCriticalNotification(ctx context.Context, title string, description string) {
+	t.sendError(ctx, discord.Notification{Title: "🚨 " + title, Description: description})
+}
+
+func (t *TelegramNotifier) SendResolvedNotification(ctx context.Context, title string, description string,
+	details string) {
+	t.sendError(ctx, discord.Notification{Title: "✅ " + title, Description: description,
+		Fields: discord.DetailsFields(details)})
+}
+
+func (t *TelegramNotifier) SendHiatusNotification(ctx context.Context, title string, description string, _ int) {
+	notification := discord.Notification{Title: title, Description: description}
+	if err := t.send(ctx, t.cfg.Config.TelegramChatID, formatMessage(notification)); err != nil {
+		t.log.Error().Err(err).Msgf("Error sending Telegram hiatus notification: %q", title)
+	}
+}
+
+// sendError sends the notification to the error chat, which falls back to the chat for chapter notifications.
+func (t *TelegramNotifier) sendError(ctx context.Context, notification discord.Notification) {
+	chatID := t.cfg.Config.TelegramErrorChatID
+	if chatID == "" {
+		chatID = t.cfg.Config.TelegramChatID
+	}
+
+	if err := t.send(ctx, chatID, formatMessage(notification)); err != nil {
+		t.log.Error().Err(err).Msgf("Error sending Telegram error notification: %q", notification.Title)
+	}
+}
+
+func (t *TelegramNotifier) send(ctx context.Context, chatID string, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "MarkdownV2",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/sendMessage", apiURL, t.cfg.Config.TelegramBotToken), bytes.NewReader(body))
+	if err != nil {
+		return errors.New("could not create Telegram request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// the url contains the bot token
+		return errors.New("could not reach the Telegram Bot API")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return errors.Wrap(err, "could not decode telegram response")
+	}
+	if !result.OK {
+		return errors.New("telegram returned status %d: %s", resp.StatusCode, result.Description)
+	}
+
+	return nil
+}
+
+// formatMessage renders a notification as a MarkdownV2 message with the title in bold and a link to the chapter.
+func formatMessage(notification discord.Notification) string {
+	var b strings.Builder
+
+	b.WriteString("*" + escape(notification.Title) + "*\n")
+	if description := strings.TrimSpace(notification.Description); description != "" {
+		b.WriteString(escape(description) + "\n")
+	}
+	for _, field := range notification.Fields {
+		b.WriteString(fmt.Sprintf("*%s:* %s\n", escape(field.Name), escape(field.Value)))
+	}
+	if notification.URL != "" {
+		b.WriteString(fmt.Sprintf("[Read chapter](%s)\n", escapeURL(notification.URL)))
+	}
+	if notification.Footer != "" {
+		b.WriteString("_" + escape(notification.Footer) + "_")
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func escape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// escapeURL escapes the characters that are reserved inside the url part of a MarkdownV2 link.
+func escapeURL(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace(s)
+}
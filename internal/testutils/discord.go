@@ -0,0 +1,125 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+
+	"tcb-bot/internal/discord"
+	"tcb-bot/internal/domain"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelMessagesRegex matches the path of the Discord API endpoint messages are sent to, the channel ID is the
+// first group
+var channelMessagesRegex = regexp.MustCompile(`/channels/(\d+)/messages$`)
+
+// SentMessage is a message the bot sent to a channel.
+type SentMessage struct {
+	ChannelID string
+	Message   discordgo.MessageSend
+}
+
+// MockSession fakes the Discord API of a session and records every message that's sent, e.g. using
+// ChannelMessageSendEmbed. All other requests succeed without doing anything.
+type MockSession struct {
+	m        sync.Mutex
+	messages []SentMessage
+}
+
+// NewTestDiscord returns a bot that sends to a MockSession instead of Discord, it's never connected to the gateway.
+func NewTestDiscord(t *testing.T) (*discord.Bot, *MockSession) {
+	t.Helper()
+
+	mock := &MockSession{}
+	session, err := discordgo.New("Bot test-token")
+	if err != nil {
+		t.Fatalf("could not create test Discord session: %v", err)
+	}
+	session.Client = &http.Client{Transport: mock}
+	session.ShouldRetryOnRateLimit = false
+
+	bot := discord.NewBot(NewTestLogger(t), NewTestConfig(), domain.NewInMemoryStore())
+	bot.SetSession(session)
+
+	return bot, mock
+}
+
+// Messages returns the sent messages in the order they were sent.
+func (s *MockSession) Messages() []SentMessage {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return append([]SentMessage(nil), s.messages...)
+}
+
+// Embeds returns the embeds of all sent messages in the order they were sent.
+func (s *MockSession) Embeds() []*discordgo.MessageEmbed {
+	var embeds []*discordgo.MessageEmbed
+	for _, message := range s.Messages() {
+		embeds = append(embeds, message.Message.Embeds...)
+	}
+
+	return embeds
+}
+
+// RoundTrip answers the requests of the session like the Discord API would.
+func (s *MockSession) RoundTrip(req *http.Request) (*http.Response, error) {
+	match := channelMessagesRegex.FindStringSubmatch(req.URL.Path)
+	if req.Method != http.MethodPost || match == nil {
+		return response(req, http.StatusNoContent, ""), nil
+	}
+
+	message, err := decodeMessage(req)
+	if err != nil {
+		return response(req, http.StatusBadRequest, `{"message": "invalid json"}`), nil
+	}
+
+	s.m.Lock()
+	s.messages = append(s.messages, SentMessage{ChannelID: match[1], Message: message})
+	id := len(s.messages)
+	s.m.Unlock()
+
+	return response(req, http.StatusOK, fmt.Sprintf(`{"id": "%d", "channel_id": "%s"}`, id, match[1])), nil
+}
+
+// decodeMessage decodes a sent message, its components are decoded as action rows because MessageSend can't decode
+// them on its own.
+func decodeMessage(req *http.Request) (discordgo.MessageSend, error) {
+	var message struct {
+		discordgo.MessageSend
+		Components []json.RawMessage `json:"components"`
+	}
+	if req.Body == nil {
+		return message.MessageSend, nil
+	}
+	if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+		return discordgo.MessageSend{}, err
+	}
+
+	for _, raw := range message.Components {
+		var row discordgo.ActionsRow
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return discordgo.MessageSend{}, err
+		}
+		message.MessageSend.Components = append(message.MessageSend.Components, row)
+	}
+
+	return message.MessageSend, nil
+}
+
+func response(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Request:    req,
+	}
+}
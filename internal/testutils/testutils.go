@@ -0,0 +1,84 @@
+// Package testutils provides the setup shared by tests, like a config with safe defaults, an in-memory database and a
+// Discord bot that sends to a fake Discord API.
+package testutils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"tcb-bot/internal/config"
+	"tcb-bot/internal/database"
+	"tcb-bot/internal/domain"
+	"tcb-bot/internal/logger"
+
+	"github.com/rs/zerolog"
+)
+
+// NewTestConfig returns the default config with safe values for tests, nothing is scraped or sent to Discord unless
+// the overrides change that. It panics if the overrides make the config invalid.
+func NewTestConfig(overrides ...func(*domain.Config)) *config.AppConfig {
+	safe := func(cfg *domain.Config) {
+		cfg.Version = "test"
+		cfg.DiscordToken = "test-token"
+		cfg.DiscordChannelID = "100000000000000000"
+		cfg.CollectedChaptersDB = ":memory:"
+		cfg.CheckOnStartup = false
+		cfg.ScrapeURLs = []string{"http://127.0.0.1"}
+	}
+
+	cfg, err := config.NewDefault(append([]func(*domain.Config){safe}, overrides...)...)
+	if err != nil {
+		panic(err)
+	}
+
+	return cfg
+}
+
+// testLogger is a logger.Logger that ignores SetLogLevel.
+type testLogger struct {
+	zerolog.Logger
+}
+
+func (testLogger) SetLogLevel(string) {}
+
+// NewTestLogger returns a logger that writes warnings and errors to the log of the test.
+func NewTestLogger(t *testing.T) logger.Logger {
+	return &testLogger{zerolog.New(zerolog.NewTestWriter(t)).Level(zerolog.WarnLevel).With().Timestamp().Logger()}
+}
+
+// NewTestDB opens an in-memory database with all migrations applied, it's closed when the test finishes.
+func NewTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	// every connection to :memory: opens a new database, so there must only be one
+	cfg := NewTestConfig(func(cfg *domain.Config) {
+		cfg.CollectedChaptersDB = ":memory:"
+		cfg.DBMaxOpenConns = 1
+		cfg.DBMaxIdleConns = 1
+	})
+
+	db := database.NewDB(NewTestLogger(t), cfg, domain.NewInMemoryStore())
+	if err := db.Open(); err != nil {
+		t.Fatalf("could not open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("could not close test database: %v", err)
+		}
+	})
+
+	return db
+}
+
+// MustParseChapter parses a chapter from its json format, see domain.ChapterInfo. The test fails if it can't be
+// parsed.
+func MustParseChapter(t *testing.T, raw string) domain.ChapterInfo {
+	t.Helper()
+
+	var chapter domain.ChapterInfo
+	if err := json.Unmarshal([]byte(raw), &chapter); err != nil {
+		t.Fatalf("could not parse chapter %q: %v", raw, err)
+	}
+
+	return chapter
+}
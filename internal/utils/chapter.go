@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IsMilestone reports whether the chapter number exactly matches one of the milestones.
+// Decimal chapters never count as milestones, e.g. 1000.5 doesn't match 1000.
+func IsMilestone(chapterNumber string, milestones []int) bool {
+	number, err := strconv.Atoi(chapterNumber)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(milestones, number)
+}
+
+// ChapterInRange reports whether the chapter number is between start and end, both inclusive. Chapter numbers are
+// compared as decimals, so 1000.5 is after 1000 and before 1001. Empty bounds are open, and chapters whose number
+// can't be compared are always in range, so they aren't missed.
+func ChapterInRange(chapter, start, end string) bool {
+	number, err := strconv.ParseFloat(chapter, 64)
+	if err != nil {
+		return true
+	}
+
+	if start != "" {
+		if bound, err := strconv.ParseFloat(start, 64); err == nil && number < bound {
+			return false
+		}
+	}
+
+	if end != "" {
+		if bound, err := strconv.ParseFloat(end, 64); err == nil && number > bound {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReleaseURL returns the url of a release, links of the website are relative to websiteURL while links of feeds
+// are absolute.
+func ReleaseURL(websiteURL, releaseLink string) string {
+	if strings.HasPrefix(releaseLink, "http://") || strings.HasPrefix(releaseLink, "https://") {
+		return releaseLink
+	}
+
+	return strings.TrimSuffix(websiteURL, "/") + releaseLink
+}
+
+// LookupTitle looks up a manga title in a map from the config. Viper lowercases all map keys,
+// so titles are compared case-insensitively.
+func LookupTitle[V any](m map[string]V, mangaTitle string) (V, bool) {
+	if value, ok := m[mangaTitle]; ok {
+		return value, true
+	}
+
+	for title, value := range m {
+		if strings.EqualFold(NormalizeTitle(title), NormalizeTitle(mangaTitle)) {
+			return value, true
+		}
+	}
+
+	var zero V
+	return zero, false
+}
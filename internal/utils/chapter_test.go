@@ -0,0 +1,68 @@
+package utils
+
+import "testing"
+
+func TestIsMilestone(t *testing.T) {
+	milestones := []int{100, 500, 1000, 1100}
+
+	tests := []struct {
+		name          string
+		chapterNumber string
+		milestones    []int
+		want          bool
+	}{
+		{name: "milestone", chapterNumber: "1000", milestones: milestones, want: true},
+		{name: "first milestone", chapterNumber: "100", milestones: milestones, want: true},
+		{name: "last milestone", chapterNumber: "1100", milestones: milestones, want: true},
+		{name: "no milestone", chapterNumber: "1001", milestones: milestones, want: false},
+		{name: "decimal after milestone", chapterNumber: "1000.5", milestones: milestones, want: false},
+		{name: "decimal zero", chapterNumber: "1000.0", milestones: milestones, want: false},
+		{name: "trailing letter", chapterNumber: "100b", milestones: milestones, want: false},
+		{name: "leading zeros", chapterNumber: "0100", milestones: milestones, want: true},
+		{name: "not a number", chapterNumber: "Oneshot", milestones: milestones, want: false},
+		{name: "empty chapter number", chapterNumber: "", milestones: milestones, want: false},
+		{name: "no milestones", chapterNumber: "1000", milestones: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsMilestone(tt.chapterNumber, tt.milestones); got != tt.want {
+				t.Errorf("IsMilestone(%q, %v) = %v, want %v", tt.chapterNumber, tt.milestones, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChapterInRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		chapter string
+		start   string
+		end     string
+		want    bool
+	}{
+		{name: "no bounds", chapter: "1000", want: true},
+		{name: "after start", chapter: "1001", start: "1000", want: true},
+		{name: "equal to start", chapter: "1000", start: "1000", want: true},
+		{name: "before start", chapter: "999", start: "1000", want: false},
+		{name: "decimal after start", chapter: "1000.5", start: "1000", want: true},
+		{name: "decimal before start", chapter: "999.5", start: "1000", want: false},
+		{name: "before end", chapter: "249", end: "250", want: true},
+		{name: "equal to end", chapter: "250", end: "250", want: true},
+		{name: "after end", chapter: "251", end: "250", want: false},
+		{name: "decimal after end", chapter: "250.5", end: "250", want: false},
+		{name: "between bounds", chapter: "150", start: "100", end: "200", want: true},
+		{name: "outside bounds", chapter: "201", start: "100", end: "200", want: false},
+		{name: "invalid chapter", chapter: "12b", start: "100", end: "200", want: true},
+		{name: "invalid start", chapter: "50", start: "one", want: true},
+		{name: "invalid end", chapter: "500", end: "two", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChapterInRange(tt.chapter, tt.start, tt.end); got != tt.want {
+				t.Errorf("ChapterInRange(%q, %q, %q) = %v, want %v", tt.chapter, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
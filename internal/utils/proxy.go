@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// ParseProxyURL parses the proxy URL and makes sure it uses a supported scheme.
+func ParseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse proxy url")
+	}
+
+	if !slices.Contains(proxySchemes, u.Scheme) {
+		return nil, errors.New("unsupported proxy scheme %q, must be one of http, https or socks5", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, errors.New("proxy url is missing a host")
+	}
+
+	return u, nil
+}
+
+// NewHTTPClient returns a http client that routes all requests through the proxy if one is set.
+func NewHTTPClient(proxyURL string, timeout time.Duration) *http.Client {
+	client := &http.Client{
+		Timeout: timeout,
+	}
+
+	if proxyURL == "" {
+		return client
+	}
+
+	u, err := ParseProxyURL(proxyURL)
+	if err != nil {
+		return client
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	client.Transport = transport
+
+	return client
+}
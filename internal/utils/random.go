@@ -0,0 +1,12 @@
+package utils
+
+import "math/rand/v2"
+
+// RandomFrom returns a random element of the pool or an empty string if the pool is empty.
+func RandomFrom(pool []string) string {
+	if len(pool) == 0 {
+		return ""
+	}
+
+	return pool[rand.IntN(len(pool))]
+}
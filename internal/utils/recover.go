@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// maxPanicMessageLength keeps panic messages within the limits of a Discord embed description.
+const maxPanicMessageLength = 1000
+
+// ErrorNotifier is the part of discord.Notifier that is used to report panics.
+type ErrorNotifier interface {
+	SendErrorNotification(ctx context.Context, title, description, details string)
+}
+
+// SafeGo runs fn and recovers from any panic inside it. The panic is logged with its stack trace and
+// reported using the notifier, if there is one. fn runs on the calling goroutine, so SafeGo is meant to
+// wrap functions that are already started on their own goroutine, like scheduler tasks and event handlers.
+func SafeGo(fn func(), log zerolog.Logger, notifier ErrorNotifier) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		var err error
+		errors.RecoverPanic(r, &err)
+		log.Error().Stack().Err(err).Msg("recovered from panic")
+
+		if notifier != nil {
+			message := []rune(fmt.Sprint(r))
+			if len(message) > maxPanicMessageLength {
+				message = append(message[:maxPanicMessageLength], '…')
+			}
+			notifier.SendErrorNotification(context.Background(), "Recovered from panic", string(message), "")
+		}
+	}()
+
+	fn()
+}
@@ -1,20 +1,117 @@
 package utils
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
 
-func ParseAndConvertTime(releaseTime, givenFormat, wantedTimeZone, wantedFormat string) (string, error) {
-	// Parse format of given release time
-	t, err := time.Parse(givenFormat, releaseTime)
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// durationDaysRegex matches the day and week units that time.ParseDuration doesn't support
+var durationDaysRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
+// ParseDuration parses a duration like time.ParseDuration, but also supports days ("d") and weeks ("w"),
+// e.g. "365d" or "2w3d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("invalid duration %q", s)
+	}
+
+	var days float64
+	rest := durationDaysRegex.ReplaceAllStringFunc(s, func(match string) string {
+		parts := durationDaysRegex.FindStringSubmatch(match)
+		value, _ := strconv.ParseFloat(parts[1], 64)
+		if parts[2] == "w" {
+			value *= 7
+		}
+		days += value
+		return ""
+	})
+
+	d := time.Duration(days * float64(24*time.Hour))
+	if rest == "" {
+		return d, nil
+	}
+
+	parsed, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, errors.New("invalid duration %q", s)
+	}
+
+	return d + parsed, nil
+}
+
+// FormatDuration formats a duration in a human-readable way, e.g. "14 days 3 hours".
+func FormatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%s %s", pluralize(days, "day"), pluralize(hours, "hour"))
+	case days > 0:
+		return pluralize(days, "day")
+	case hours > 0:
+		return pluralize(hours, "hour")
+	default:
+		return pluralize(int(d.Minutes()), "minute")
+	}
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// QuietHoursEnd checks if the given time lies within the quiet hours between start and end,
+// formatted as "15:04" in the given time zone. The window may span midnight. If the time is
+// within the quiet hours, the end of the window is returned as well.
+func QuietHoursEnd(now time.Time, start, end, timeZone string) (time.Time, bool, error) {
+	if start == "" || end == "" || start == end {
+		return time.Time{}, false, nil
+	}
+
+	location, err := time.LoadLocation(timeZone)
 	if err != nil {
-		return "", err
+		return time.Time{}, false, err
 	}
+	now = now.In(location)
+
+	startTime, err := clockTime(now, start)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	endTime, err := clockTime(now, end)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if startTime.Before(endTime) {
+		return endTime, !now.Before(startTime) && now.Before(endTime), nil
+	}
+
+	// window spans midnight, e.g. 23:00 - 07:00
+	if now.Before(endTime) {
+		return endTime, true, nil
+	}
+	if !now.Before(startTime) {
+		return endTime.AddDate(0, 0, 1), true, nil
+	}
+
+	return time.Time{}, false, nil
+}
 
-	// Convert to a specific time zone.
-	location, err := time.LoadLocation(wantedTimeZone)
+// clockTime returns the given "15:04" clock time on the same day as day.
+func clockTime(day time.Time, clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
 	if err != nil {
-		return "", err
+		return time.Time{}, err
 	}
-	t = t.In(location)
 
-	return t.Format(wantedFormat), nil
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
 }
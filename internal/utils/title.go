@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+
+	"tcb-bot/internal/domain"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+const maxFuzzyDistance = 2
+
+// zeroWidthReplacer handles invisible characters that sites use inside of titles. Zero-width spaces separate words,
+// so they're replaced by a space, the joiners are removed.
+var zeroWidthReplacer = strings.NewReplacer("\u200b", " ", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "")
+
+// NormalizeTitle makes titles comparable that only differ in their unicode representation. Full-width characters
+// are folded into their half-width form and the result is composed using NFC, so combining diacritics match their
+// pre-composed form. Zero-width characters are handled by zeroWidthReplacer and runs of whitespace are
+// collapsed into a single space.
+func NormalizeTitle(s string) string {
+	s = zeroWidthReplacer.Replace(norm.NFC.String(width.Fold.String(s)))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// TitleMatches compares a scraped manga title with a watched one, ignoring case and differences removed
+// by NormalizeTitle. If fuzzy is set, titles also match if they're at most two edits apart. Every title
+// matches the watched manga "*".
+func TitleMatches(scraped, watched string, fuzzy bool) bool {
+	if watched == domain.WatchAllMangas {
+		return true
+	}
+
+	scraped = strings.ToLower(NormalizeTitle(scraped))
+	watched = strings.ToLower(NormalizeTitle(watched))
+
+	if scraped == watched {
+		return true
+	}
+
+	return fuzzy && levenshtein([]rune(scraped), []rune(watched)) <= maxFuzzyDistance
+}
+
+// levenshtein returns the number of single rune edits needed to turn a into b.
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// CanonicalTitle returns the manga the scraped title is an alias of, or the scraped title if it's no alias.
+// Viper lowercases the manga titles of the aliases, so the spelling of the matching watched manga is used if
+// there is one.
+func CanonicalTitle(scraped string, aliases map[string][]string, watched []string) string {
+	for canonical, alternates := range aliases {
+		if !slices.ContainsFunc(alternates, func(alias string) bool { return TitleMatches(scraped, alias, false) }) {
+			continue
+		}
+
+		if i := slices.IndexFunc(watched, func(title string) bool {
+			return title != domain.WatchAllMangas && TitleMatches(canonical, title, false)
+		}); i >= 0 {
+			return watched[i]
+		}
+		return canonical
+	}
+
+	return scraped
+}
@@ -0,0 +1,100 @@
+package utils
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "plain", title: "One Piece", want: "One Piece"},
+		{name: "surrounding whitespace", title: "  One Piece\t", want: "One Piece"},
+		{name: "whitespace runs", title: "One \t\n Piece", want: "One Piece"},
+		{name: "fullwidth ascii", title: "Ｏｎｅ Ｐｉｅｃｅ", want: "One Piece"},
+		{name: "fullwidth space", title: "One\u3000Piece", want: "One Piece"},
+		{name: "halfwidth katakana", title: "ﾜﾝﾋﾟｰｽ", want: "ワンピース"},
+		{name: "precomposed diacritic", title: "Pok\u00e9mon", want: "Pok\u00e9mon"},
+		{name: "combining diacritic", title: "Poke\u0301mon", want: "Pok\u00e9mon"},
+		{name: "zero-width space between words", title: "One\u200bPiece", want: "One Piece"},
+		{name: "zero-width space next to space", title: "One \u200bPiece", want: "One Piece"},
+		{name: "zero-width joiner", title: "One Pi\u200dece", want: "One Piece"},
+		{name: "byte order mark", title: "\ufeffOne Piece", want: "One Piece"},
+		{name: "compatibility characters are kept", title: "Ｘ①", want: "X①"},
+		{name: "empty", title: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTitle(tt.title); got != tt.want {
+				t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		scraped string
+		watched string
+		fuzzy   bool
+		want    bool
+	}{
+		{name: "equal", scraped: "One Piece", watched: "One Piece", want: true},
+		{name: "different case", scraped: "one piece", watched: "One Piece", want: true},
+		{name: "trailing space in config", scraped: "One Piece", watched: "One Piece ", want: true},
+		{name: "different manga", scraped: "One Punch Man", watched: "One Piece", want: false},
+		{name: "prefix is no match", scraped: "One Piece Party", watched: "One Piece", want: false},
+		{name: "fullwidth scraped title", scraped: "Ｏｎｅ Ｐｉｅｃｅ", watched: "One Piece", want: true},
+		{name: "zero-width space", scraped: "One\u200bPiece", watched: "One Piece", want: true},
+		{name: "decomposed diacritic", scraped: "Poke\u0301mon Adventures", watched: "Pok\u00e9mon Adventures", want: true},
+		{name: "uppercase diacritic", scraped: "POKE\u0301MON ADVENTURES", watched: "Pok\u00e9mon Adventures", want: true},
+		{name: "japanese title", scraped: "ﾜﾝﾋﾟｰｽ", watched: "ワンピース", want: true},
+		{name: "typo without fuzzy", scraped: "One Piece", watched: "One Peice", want: false},
+		{name: "typo with fuzzy", scraped: "One Piece", watched: "One Peice", fuzzy: true, want: true},
+		{name: "two edits with fuzzy", scraped: "Jujutsu Kaisen", watched: "Jujutsu Kaisin!", fuzzy: true, want: true},
+		{name: "three edits with fuzzy", scraped: "Jujutsu Kaisen", watched: "Jujitsu Kaisin!", fuzzy: true, want: false},
+		{name: "edits count runes", scraped: "ワンピース", watched: "ワンピー", fuzzy: true, want: true},
+		{name: "watch all mangas", scraped: "Chainsaw Man", watched: "*", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TitleMatches(tt.scraped, tt.watched, tt.fuzzy); got != tt.want {
+				t.Errorf("TitleMatches(%q, %q, %v) = %v, want %v", tt.scraped, tt.watched, tt.fuzzy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalTitle(t *testing.T) {
+	// viper lowercases the keys of the aliases
+	aliases := map[string][]string{
+		"jujutsu kaisen": {"Jujutsu Kaisen (Official)", "JJK"},
+		"one piece":      {"One Piece (Official)"},
+	}
+	watched := []string{"*", "Jujutsu Kaisen"}
+
+	tests := []struct {
+		name    string
+		scraped string
+		want    string
+	}{
+		{name: "alias of a watched manga", scraped: "Jujutsu Kaisen (Official)", want: "Jujutsu Kaisen"},
+		{name: "second alias", scraped: "JJK", want: "Jujutsu Kaisen"},
+		{name: "alias with different case", scraped: "jujutsu kaisen (official)", want: "Jujutsu Kaisen"},
+		{name: "alias with zero-width space", scraped: "Jujutsu\u200bKaisen (Official)", want: "Jujutsu Kaisen"},
+		{name: "alias of a manga that isn't watched", scraped: "One Piece (Official)", want: "one piece"},
+		{name: "canonical title", scraped: "Jujutsu Kaisen", want: "Jujutsu Kaisen"},
+		{name: "no alias", scraped: "Chainsaw Man", want: "Chainsaw Man"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalTitle(tt.scraped, aliases, watched); got != tt.want {
+				t.Errorf("CanonicalTitle(%q) = %q, want %q", tt.scraped, got, tt.want)
+			}
+		})
+	}
+}
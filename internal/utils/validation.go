@@ -1,16 +1,44 @@
 package utils
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
 
 var (
-	releaseTitleRegex = regexp.MustCompile(`^(.+?) Chapter (\d+(\.\d+)?)$`)
+	// releaseTitleRegex matches e.g. "One Piece Chapter 1000", "One Piece Volume 5 Chapter 45",
+	// "One Piece Chapter 1000.5" and "One Piece Chapter 12b"
+	releaseTitleRegex = regexp.MustCompile(`^(.+?)(?:\s+(?:Volume|Vol\.)\s*(\d+))?\s+Chapter\s+(\d+(?:\.\d+)?[a-zA-Z]?)$`)
 	releaseLinkRegex  = regexp.MustCompile(`^/chapters/\d+/[a-z0-9-]+-chapter-\d+.*$`)
+
+	// volumeRegex matches a volume without a manga title, e.g. the "Vol. 3" of "Vol. 3 Chapter 2"
+	volumeRegex = regexp.MustCompile(`^(?:Volume|Vol\.)\s*\d+$`)
 )
 
 func ValidateReleaseTitle(releaseTitle string) bool {
-	return releaseTitleRegex.MatchString(releaseTitle)
+	_, _, _, err := ParseReleaseTitle(releaseTitle)
+	return err == nil
 }
 
 func ValidateReleaseLink(releaseLink string) bool {
 	return releaseLinkRegex.MatchString(releaseLink)
 }
+
+// ParseReleaseTitle splits a scraped release title into the manga title, the volume and the chapter number.
+// The volume is empty if the release title doesn't contain one. Trailing letters of the chapter number are
+// lowercased, e.g. "12B" becomes "12b". Release titles without a manga title, like "Vol. 3 Chapter 2", are invalid.
+func ParseReleaseTitle(releaseTitle string) (mangaTitle, volume, chapterNumber string, err error) {
+	matches := releaseTitleRegex.FindStringSubmatch(strings.TrimSpace(releaseTitle))
+	if matches == nil {
+		return "", "", "", errors.New("invalid release title: %q", releaseTitle)
+	}
+
+	mangaTitle = strings.TrimSpace(matches[1])
+	if volumeRegex.MatchString(mangaTitle) {
+		return "", "", "", errors.New("release title has no manga title: %q", releaseTitle)
+	}
+
+	return mangaTitle, matches[2], strings.ToLower(matches[3]), nil
+}
@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestParseReleaseTitle(t *testing.T) {
+	tests := []struct {
+		releaseTitle  string
+		mangaTitle    string
+		volume        string
+		chapterNumber string
+		wantErr       bool
+	}{
+		{releaseTitle: "One Piece Chapter 1000", mangaTitle: "One Piece", chapterNumber: "1000"},
+		{releaseTitle: "One Piece Chapter 1000.5", mangaTitle: "One Piece", chapterNumber: "1000.5"},
+		{releaseTitle: "One Piece Chapter 12b", mangaTitle: "One Piece", chapterNumber: "12b"},
+		{releaseTitle: "One Piece Chapter 12B", mangaTitle: "One Piece", chapterNumber: "12b"},
+		{releaseTitle: "One Piece Chapter 0", mangaTitle: "One Piece", chapterNumber: "0"},
+		{releaseTitle: "One Piece Volume 5 Chapter 45", mangaTitle: "One Piece", volume: "5", chapterNumber: "45"},
+		{releaseTitle: "One Piece Vol. 5 Chapter 45", mangaTitle: "One Piece", volume: "5", chapterNumber: "45"},
+		{releaseTitle: "One Piece Vol.5 Chapter 45.5", mangaTitle: "One Piece", volume: "5", chapterNumber: "45.5"},
+		{releaseTitle: "  One Piece Chapter 1000  ", mangaTitle: "One Piece", chapterNumber: "1000"},
+		{releaseTitle: "One  Piece   Chapter 1000", mangaTitle: "One  Piece", chapterNumber: "1000"},
+		{releaseTitle: "Chainsaw Man Chapter 150", mangaTitle: "Chainsaw Man", chapterNumber: "150"},
+		{releaseTitle: "Jujutsu Kaisen (Official) Chapter 250", mangaTitle: "Jujutsu Kaisen (Official)", chapterNumber: "250"},
+		{releaseTitle: "Kagurabachi: Chapter 1 Chapter 2", mangaTitle: "Kagurabachi: Chapter 1", chapterNumber: "2"},
+		{releaseTitle: "Ｏｎｅ Ｐｉｅｃｅ Chapter 1000", mangaTitle: "Ｏｎｅ Ｐｉｅｃｅ", chapterNumber: "1000"},
+		{releaseTitle: "Volume 5 Chapter 45", wantErr: true},
+		{releaseTitle: "Vol. 3 Chapter 2", wantErr: true},
+		{releaseTitle: "Chapter 1000", wantErr: true},
+		{releaseTitle: "One Piece", wantErr: true},
+		{releaseTitle: "One Piece Chapter", wantErr: true},
+		{releaseTitle: "One Piece Chapter one", wantErr: true},
+		{releaseTitle: "One Piece Chapter 1000.", wantErr: true},
+		{releaseTitle: "One Piece Chapter 12bc", wantErr: true},
+		{releaseTitle: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.releaseTitle, func(t *testing.T) {
+			mangaTitle, volume, chapterNumber, err := ParseReleaseTitle(tt.releaseTitle)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseReleaseTitle(%q) = %q, %q, %q, want an error", tt.releaseTitle, mangaTitle, volume,
+						chapterNumber)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReleaseTitle(%q) returned an error: %v", tt.releaseTitle, err)
+			}
+
+			if mangaTitle != tt.mangaTitle || volume != tt.volume || chapterNumber != tt.chapterNumber {
+				t.Errorf("ParseReleaseTitle(%q) = %q, %q, %q, want %q, %q, %q", tt.releaseTitle, mangaTitle, volume,
+					chapterNumber, tt.mangaTitle, tt.volume, tt.chapterNumber)
+			}
+		})
+	}
+}
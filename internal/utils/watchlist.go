@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+
+	"tcb-bot/internal/domain"
+)
+
+func MarshalWatchlist(watchlist domain.Watchlist) ([]byte, error) {
+	if watchlist.WatchedMangas == nil {
+		watchlist.WatchedMangas = []string{}
+	}
+	if watchlist.MangaChannels == nil {
+		watchlist.MangaChannels = map[string]string{}
+	}
+
+	return json.MarshalIndent(watchlist, "", "  ")
+}
+
+// UnmarshalWatchlist parses an exported watchlist, empty manga titles are dropped.
+func UnmarshalWatchlist(data []byte) (domain.Watchlist, error) {
+	var watchlist domain.Watchlist
+	if err := json.Unmarshal(data, &watchlist); err != nil {
+		return domain.Watchlist{}, err
+	}
+
+	watchedMangas := make([]string, 0, len(watchlist.WatchedMangas))
+	for _, manga := range watchlist.WatchedMangas {
+		if manga = strings.TrimSpace(manga); manga != "" {
+			watchedMangas = append(watchedMangas, manga)
+		}
+	}
+	watchlist.WatchedMangas = watchedMangas
+
+	if watchlist.MangaChannels == nil {
+		watchlist.MangaChannels = map[string]string{}
+	}
+
+	return watchlist, nil
+}